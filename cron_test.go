@@ -0,0 +1,66 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronExpression_Next(t *testing.T) {
+	from := time.Date(2025, time.January, 1, 10, 7, 30, 0, time.UTC) // wednesday
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{"* * * * *", time.Date(2025, time.January, 1, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, time.January, 1, 10, 15, 0, 0, time.UTC)},
+		{"0 9 * * *", time.Date(2025, time.January, 2, 9, 0, 0, 0, time.UTC)},
+		{"0 9 * * 1-5", time.Date(2025, time.January, 2, 9, 0, 0, 0, time.UTC)},
+		{"30 8 * * 7", time.Date(2025, time.January, 5, 8, 30, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		cron, err := ParseCronExpression(tt.expr)
+		if err != nil {
+			t.Errorf("expected no error parsing %q, got %v", tt.expr, err)
+			continue
+		}
+
+		next, err := cron.Next(from)
+		if err != nil {
+			t.Errorf("expected no error at Next for %q, got %v", tt.expr, err)
+			continue
+		}
+
+		if !next.Equal(tt.expected) {
+			t.Errorf("expected %q to fire at %v, got %v", tt.expr, tt.expected, next)
+		}
+	}
+}
+
+func TestParseCronExpression_Invalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "a * * * *"} {
+		if _, err := ParseCronExpression(expr); err == nil {
+			t.Errorf("expected error parsing %q, got nil", expr)
+		}
+	}
+}
+
+func TestHookRecurringSchedule_Next_TimeZone(t *testing.T) {
+	r := HookRecurringSchedule{
+		CronExpression: "0 9 * * *",
+		TimeZone:       "America/Sao_Paulo",
+	}
+
+	next, err := r.Next(time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	expected := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+	if !next.Equal(expected) {
+		t.Errorf("expected next run at %v, got %v", expected, next)
+	}
+}
@@ -0,0 +1,401 @@
+package nautilus
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// PayloadFilter is a predicate over a json payload, written as comparisons between
+// JSONPath-like paths and literals combined with &&, || and !.
+//
+// e.g $.status == "shipped" && ($.total >= 100 || $.customer.vip)
+type PayloadFilter struct {
+	source string
+	expr   filterNode
+}
+
+type filterNode interface {
+	eval(payload any) any
+}
+
+type (
+	filterPath    []any // string keys and int indexes
+	filterLiteral struct{ value any }
+	filterNot     struct{ node filterNode }
+	filterLogical struct {
+		op          string
+		left, right filterNode
+	}
+	filterComparison struct {
+		op          string
+		left, right filterNode
+	}
+)
+
+func ParsePayloadFilter(expr string) (*PayloadFilter, error) {
+	tokens, err := tokenizeFilter(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected token %q in filter", p.tokens[p.pos])
+	}
+
+	return &PayloadFilter{source: expr, expr: node}, nil
+}
+
+// Match evaluates the filter against the payload.
+func (p *PayloadFilter) Match(payload json.RawMessage) (bool, error) {
+	var data any
+	if len(payload) > 0 {
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return false, err
+		}
+	}
+
+	return truthy(p.expr.eval(data)), nil
+}
+
+func (p filterPath) eval(payload any) any {
+	current := payload
+	for _, segment := range p {
+		switch s := segment.(type) {
+		case string:
+			m, ok := current.(map[string]any)
+			if !ok {
+				return nil
+			}
+			current = m[s]
+		case int:
+			a, ok := current.([]any)
+			if !ok || s < 0 || s >= len(a) {
+				return nil
+			}
+			current = a[s]
+		}
+	}
+
+	return current
+}
+
+func (p filterLiteral) eval(payload any) any {
+	return p.value
+}
+
+func (p filterNot) eval(payload any) any {
+	return !truthy(p.node.eval(payload))
+}
+
+func (p filterLogical) eval(payload any) any {
+	left := truthy(p.left.eval(payload))
+	if p.op == "&&" {
+		return left && truthy(p.right.eval(payload))
+	}
+
+	return left || truthy(p.right.eval(payload))
+}
+
+func (p filterComparison) eval(payload any) any {
+	left, right := p.left.eval(payload), p.right.eval(payload)
+
+	switch p.op {
+	case "==":
+		return equal(left, right)
+	case "!=":
+		return !equal(left, right)
+	}
+
+	var cmp int
+	switch l := left.(type) {
+	case float64:
+		r, ok := right.(float64)
+		if !ok {
+			return false
+		}
+		cmp = compare(l, r)
+	case string:
+		r, ok := right.(string)
+		if !ok {
+			return false
+		}
+		cmp = strings.Compare(l, r)
+	default:
+		return false
+	}
+
+	switch p.op {
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func compare(l, r float64) int {
+	switch {
+	case l < r:
+		return -1
+	case l > r:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func equal(l, r any) bool {
+	switch l.(type) {
+	case map[string]any, []any:
+		return false
+	}
+
+	return l == r
+}
+
+func truthy(v any) bool {
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	default:
+		return true
+	}
+}
+
+type filterParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *filterParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = filterLogical{op: "||", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = filterLogical{op: "&&", left: left, right: right}
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	if p.peek() == "!" {
+		p.pos++
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{node: node}, nil
+	}
+
+	if p.peek() == "(" {
+		p.pos++
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, errors.New("missing closing parenthesis in filter")
+		}
+		p.pos++
+		return node, nil
+	}
+
+	left, err := p.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+
+	switch op := p.peek(); op {
+	case "==", "!=", "<", "<=", ">", ">=":
+		p.pos++
+		right, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return filterComparison{op: op, left: left, right: right}, nil
+	}
+
+	return left, nil
+}
+
+func (p *filterParser) parseOperand() (filterNode, error) {
+	token := p.peek()
+	if token == "" {
+		return nil, errors.New("unexpected end of filter")
+	}
+	p.pos++
+
+	switch {
+	case token == "true":
+		return filterLiteral{true}, nil
+	case token == "false":
+		return filterLiteral{false}, nil
+	case token == "null":
+		return filterLiteral{nil}, nil
+	case strings.HasPrefix(token, `"`) || strings.HasPrefix(token, "'"):
+		return filterLiteral{token[1 : len(token)-1]}, nil
+	case strings.HasPrefix(token, "$"):
+		return parseFilterPath(token)
+	}
+
+	n, err := strconv.ParseFloat(token, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected token %q in filter", token)
+	}
+
+	return filterLiteral{n}, nil
+}
+
+func parseFilterPath(token string) (filterPath, error) {
+	path := filterPath{}
+	rest := token[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+			key := rest[1 : end+1]
+			if key == "" {
+				return nil, fmt.Errorf("invalid path %q in filter", token)
+			}
+			path = append(path, key)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid path %q in filter", token)
+			}
+			segment := rest[1:end]
+			if len(segment) >= 2 && (segment[0] == '\'' || segment[0] == '"') {
+				path = append(path, segment[1:len(segment)-1])
+			} else {
+				i, err := strconv.Atoi(segment)
+				if err != nil {
+					return nil, fmt.Errorf("invalid path %q in filter", token)
+				}
+				path = append(path, i)
+			}
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("invalid path %q in filter", token)
+		}
+	}
+
+	return path, nil
+}
+
+func tokenizeFilter(expr string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(expr); {
+		c, size := utf8.DecodeRuneInString(expr[i:])
+		switch {
+		case unicode.IsSpace(c):
+			i += size
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case strings.HasPrefix(expr[i:], "&&") || strings.HasPrefix(expr[i:], "||") ||
+			strings.HasPrefix(expr[i:], "==") || strings.HasPrefix(expr[i:], "!=") ||
+			strings.HasPrefix(expr[i:], "<=") || strings.HasPrefix(expr[i:], ">="):
+			tokens = append(tokens, expr[i:i+2])
+			i += 2
+		case c == '!' || c == '<' || c == '>':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(expr[i+1:], c)
+			if end < 0 {
+				return nil, errors.New("unterminated string in filter")
+			}
+			tokens = append(tokens, expr[i:i+end+2])
+			i += end + 2
+		case c == '$':
+			start := i
+			for i < len(expr) && !isFilterDelimiter(expr[i:]) {
+				if expr[i] == '[' {
+					end := strings.IndexByte(expr[i:], ']')
+					if end < 0 {
+						return nil, errors.New("unterminated path index in filter")
+					}
+					i += end
+				}
+				_, size := utf8.DecodeRuneInString(expr[i:])
+				i += size
+			}
+			tokens = append(tokens, expr[start:i])
+		default:
+			start := i
+			for i < len(expr) && !isFilterDelimiter(expr[i:]) {
+				_, size := utf8.DecodeRuneInString(expr[i:])
+				i += size
+			}
+			if start == i {
+				return nil, fmt.Errorf("unexpected character %q in filter", c)
+			}
+			tokens = append(tokens, expr[start:i])
+		}
+	}
+
+	return tokens, nil
+}
+
+// isFilterDelimiter reports whether the rune at the start of s ends a path or a literal.
+func isFilterDelimiter(s string) bool {
+	c, _ := utf8.DecodeRuneInString(s)
+	return unicode.IsSpace(c) || strings.ContainsRune("()!=<>&|", c)
+}
@@ -0,0 +1,53 @@
+package nautilus
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPayloadFilter_Match(t *testing.T) {
+	payload := json.RawMessage(`{"status": "shipped", "total": 150, "customer": {"vip": true, "tags": ["b2b"]}, "note": null, "city": "São Paulo"}`)
+
+	tests := []struct {
+		expr     string
+		expected bool
+	}{
+		{`$.status == "shipped"`, true},
+		{`$.status != 'shipped'`, false},
+		{`$.total >= 100 && $.total < 200`, true},
+		{`$.total > 150`, false},
+		{`$.customer.vip`, true},
+		{`!$.customer.vip || $.status == "pending"`, false},
+		{`($.status == "pending" || $.status == "shipped") && $.customer.tags[0] == "b2b"`, true},
+		{`$['customer']['tags'][1] == null`, true},
+		{`$.note == null && $.missing == null`, true},
+		{`$.missing.nested == 1`, false},
+		{"$.city\u00a0==\u00a0\"São Paulo\"", true},
+	}
+
+	for _, tt := range tests {
+		filter, err := ParsePayloadFilter(tt.expr)
+		if err != nil {
+			t.Errorf("expected no error parsing %q, got %v", tt.expr, err)
+			continue
+		}
+
+		match, err := filter.Match(payload)
+		if err != nil {
+			t.Errorf("expected no error matching %q, got %v", tt.expr, err)
+			continue
+		}
+
+		if match != tt.expected {
+			t.Errorf("expected %q to be %v, got %v", tt.expr, tt.expected, match)
+		}
+	}
+}
+
+func TestParsePayloadFilter_Invalid(t *testing.T) {
+	for _, expr := range []string{``, `$.status ==`, `($.status == "a"`, `$.status = "a"`, `"unterminated`, `$.status == "a" "b"`} {
+		if _, err := ParsePayloadFilter(expr); err == nil {
+			t.Errorf("expected error parsing %q, got nil", expr)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package nautilus
+
+import (
+	"sync"
+	"time"
+)
+
+// inFlightRegistry tracks the schedules dispatched to the workers, from the moment they are
+// queued until their execution finishes, so a schedule is dispatched once at a time. Entries
+// expire after the lease, so schedules of stuck workers are eventually dispatched again.
+type inFlightRegistry struct {
+	l      sync.Mutex
+	lease  time.Duration
+	leases map[string]time.Time
+}
+
+func newInFlightRegistry(lease time.Duration) *inFlightRegistry {
+	return &inFlightRegistry{
+		lease:  lease,
+		leases: make(map[string]time.Time),
+	}
+}
+
+// acquire registers the schedule, reporting false if it is already in flight.
+func (r *inFlightRegistry) acquire(id string, now time.Time) bool {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	if expiresAt, ok := r.leases[id]; ok && now.Before(expiresAt) {
+		return false
+	}
+
+	r.leases[id] = now.Add(r.lease)
+
+	return true
+}
+
+func (r *inFlightRegistry) release(id string) {
+	r.l.Lock()
+	defer r.l.Unlock()
+
+	delete(r.leases, id)
+}
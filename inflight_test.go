@@ -0,0 +1,32 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInFlightRegistry(t *testing.T) {
+	r := newInFlightRegistry(time.Minute)
+	now := time.Now()
+
+	if !r.acquire("schedule-1", now) {
+		t.Fatal("expected schedule to be acquired")
+	}
+
+	if r.acquire("schedule-1", now.Add(time.Second)) {
+		t.Error("expected schedule in flight not to be acquired again")
+	}
+
+	if !r.acquire("schedule-2", now) {
+		t.Error("expected another schedule to be acquired")
+	}
+
+	if !r.acquire("schedule-1", now.Add(2*time.Minute)) {
+		t.Error("expected schedule to be acquired once the lease expired")
+	}
+
+	r.release("schedule-2")
+	if !r.acquire("schedule-2", now) {
+		t.Error("expected released schedule to be acquired")
+	}
+}
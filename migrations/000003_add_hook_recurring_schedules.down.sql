@@ -0,0 +1,5 @@
+BEGIN;
+
+DROP TABLE hook_recurring_schedules;
+
+COMMIT;
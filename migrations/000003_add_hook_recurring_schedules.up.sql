@@ -0,0 +1,17 @@
+BEGIN;
+
+CREATE TABLE hook_recurring_schedules (
+    id TEXT PRIMARY KEY,
+    hook_configuration_id TEXT NOT NULL REFERENCES hook_configurations(id) ON DELETE CASCADE,
+    cron_expression VARCHAR(255) NOT NULL,
+    time_zone VARCHAR(255) NOT NULL DEFAULT '',
+    payload JSONB,
+    payload_generator TEXT,
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_run_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX hook_recurring_schedules_next_run_at_idx ON hook_recurring_schedules (next_run_at);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX hook_schedules_ordering_key_idx;
+ALTER TABLE hook_schedules DROP ordering_key;
+ALTER TABLE hook_definitions DROP ordering_failure_policy;
+COMMIT;
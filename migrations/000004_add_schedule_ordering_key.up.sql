@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE hook_definitions ADD ordering_failure_policy VARCHAR(50) NOT NULL DEFAULT '';
+ALTER TABLE hook_schedules ADD ordering_key TEXT;
+CREATE INDEX hook_schedules_ordering_key_idx ON hook_schedules (hook_configuration_id, ordering_key, created_at) WHERE ordering_key IS NOT NULL;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE hook_schedules DROP priority;
+ALTER TABLE hook_definitions DROP priority;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE hook_definitions ADD priority INT NOT NULL DEFAULT 0;
+ALTER TABLE hook_schedules ADD priority INT NOT NULL DEFAULT 0;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+DROP INDEX hook_schedules_configuration_status_idx;
+DROP INDEX hook_executions_batch_id_idx;
+ALTER TABLE hook_executions DROP batch_id;
+ALTER TABLE hook_definitions DROP batch_max_wait;
+ALTER TABLE hook_definitions DROP batch_max_size;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+ALTER TABLE hook_definitions ADD batch_max_size INT NOT NULL DEFAULT 0;
+ALTER TABLE hook_definitions ADD batch_max_wait BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE hook_executions ADD batch_id TEXT;
+CREATE INDEX hook_executions_batch_id_idx ON hook_executions (batch_id) WHERE batch_id IS NOT NULL;
+CREATE INDEX hook_schedules_configuration_status_idx ON hook_schedules (hook_configuration_id, status, created_at);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX hook_configurations_hook_definition_id_idx;
+DROP INDEX hook_schedules_group_id_idx;
+ALTER TABLE hook_schedules DROP group_id;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD group_id TEXT;
+CREATE INDEX hook_schedules_group_id_idx ON hook_schedules (group_id) WHERE group_id IS NOT NULL;
+CREATE INDEX hook_configurations_hook_definition_id_idx ON hook_configurations (hook_definition_id);
+COMMIT;
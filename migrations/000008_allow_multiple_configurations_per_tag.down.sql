@@ -0,0 +1,11 @@
+BEGIN;
+DROP INDEX hook_configurations_hook_definition_id_tag_idx;
+-- keep only the oldest configuration of each definition and tag
+DELETE FROM hook_configurations c
+    USING hook_configurations o
+    WHERE c.hook_definition_id = o.hook_definition_id
+        AND c.tag = o.tag
+        AND (c.created_at, c.id) > (o.created_at, o.id);
+ALTER TABLE hook_configurations DROP disabled;
+ALTER TABLE hook_configurations ADD CONSTRAINT hook_configurations_tag_hook_definition_id_key UNIQUE (tag, hook_definition_id);
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE hook_configurations DROP CONSTRAINT hook_configurations_tag_hook_definition_id_key;
+ALTER TABLE hook_configurations ADD disabled BOOLEAN NOT NULL DEFAULT false;
+CREATE INDEX hook_configurations_hook_definition_id_tag_idx ON hook_configurations (hook_definition_id, tag);
+COMMIT;
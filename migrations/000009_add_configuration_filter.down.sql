@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE hook_configurations DROP filter;
+COMMIT;
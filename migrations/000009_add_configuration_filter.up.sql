@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE hook_configurations ADD filter TEXT;
+COMMIT;
@@ -0,0 +1,7 @@
+BEGIN;
+
+DELETE FROM hook_configurations WHERE subscription_id IS NOT NULL;
+ALTER TABLE hook_configurations DROP subscription_id;
+DROP TABLE hook_subscriptions;
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+CREATE TABLE hook_subscriptions (
+    id TEXT PRIMARY KEY,
+    tag VARCHAR(255) NOT NULL,
+    url VARCHAR(255) NOT NULL,
+    hook_definition_patterns TEXT[] NOT NULL,
+    client_secret TEXT,
+    client_rsa_private_key TEXT,
+    filter TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX hook_subscriptions_tag_idx ON hook_subscriptions (tag);
+
+ALTER TABLE hook_configurations ADD subscription_id TEXT REFERENCES hook_subscriptions(id) ON DELETE CASCADE;
+
+COMMIT;
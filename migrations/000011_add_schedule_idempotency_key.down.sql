@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX hook_schedules_idempotency_key_idx;
+ALTER TABLE hook_schedules DROP idempotency_key;
+COMMIT;
@@ -0,0 +1,4 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD idempotency_key TEXT;
+CREATE UNIQUE INDEX hook_schedules_idempotency_key_idx ON hook_schedules (hook_configuration_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
+COMMIT;
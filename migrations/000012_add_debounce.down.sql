@@ -0,0 +1,6 @@
+BEGIN;
+DROP INDEX hook_schedules_coalescing_key_idx;
+ALTER TABLE hook_schedules DROP coalescing_key;
+ALTER TABLE hook_definitions DROP debounce_max_wait;
+ALTER TABLE hook_definitions DROP debounce_window;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE hook_definitions ADD debounce_window BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE hook_definitions ADD debounce_max_wait BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE hook_schedules ADD coalescing_key TEXT;
+CREATE INDEX hook_schedules_coalescing_key_idx ON hook_schedules (hook_configuration_id, coalescing_key) WHERE coalescing_key IS NOT NULL AND current_attempt = 0;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX hook_schedules_payload_hash_idx;
+ALTER TABLE hook_schedules DROP payload_hash;
+ALTER TABLE hook_definitions DROP deduplication_window;
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE hook_definitions ADD deduplication_window BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE hook_schedules ADD payload_hash TEXT;
+CREATE INDEX hook_schedules_payload_hash_idx ON hook_schedules (hook_configuration_id, payload_hash, created_at) WHERE payload_hash IS NOT NULL;
+COMMIT;
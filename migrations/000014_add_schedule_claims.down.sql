@@ -0,0 +1,5 @@
+BEGIN;
+DROP INDEX hook_schedules_status_claimed_until_idx;
+ALTER TABLE hook_schedules DROP claimed_until;
+ALTER TABLE hook_schedules DROP claimed_by;
+COMMIT;
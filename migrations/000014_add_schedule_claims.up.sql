@@ -0,0 +1,5 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD claimed_by TEXT;
+ALTER TABLE hook_schedules ADD claimed_until TIMESTAMP WITH TIME ZONE;
+CREATE INDEX hook_schedules_status_claimed_until_idx ON hook_schedules (status, claimed_until);
+COMMIT;
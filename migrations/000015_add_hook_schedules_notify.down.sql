@@ -0,0 +1,4 @@
+BEGIN;
+DROP TRIGGER hook_schedules_notify ON hook_schedules;
+DROP FUNCTION nautilus_notify_hook_schedule();
+COMMIT;
@@ -0,0 +1,12 @@
+BEGIN;
+CREATE FUNCTION nautilus_notify_hook_schedule() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('nautilus_hook_schedules', NEW.id);
+    RETURN NEW;
+COMMIT;
+$$ LANGUAGE plpgsql;
+
+-- upserts updating an existing schedule do not fire insert triggers
+CREATE TRIGGER hook_schedules_notify AFTER INSERT ON hook_schedules
+FOR EACH ROW EXECUTE FUNCTION nautilus_notify_hook_schedule();
+COMMIT;
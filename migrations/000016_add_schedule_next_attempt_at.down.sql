@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX hook_schedules_due_idx;
+ALTER TABLE hook_schedules DROP next_attempt_at;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD next_attempt_at TIMESTAMP WITH TIME ZONE;
+UPDATE hook_schedules SET next_attempt_at = COALESCE(updated_at, created_at);
+ALTER TABLE hook_schedules ALTER COLUMN next_attempt_at SET NOT NULL;
+CREATE INDEX hook_schedules_due_idx ON hook_schedules (priority DESC, next_attempt_at, id) WHERE status = 'scheduled';
+COMMIT;
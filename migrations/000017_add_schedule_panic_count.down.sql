@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE hook_schedules DROP panic_count;
+COMMIT;
@@ -0,0 +1,3 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD panic_count INT NOT NULL DEFAULT 0;
+COMMIT;
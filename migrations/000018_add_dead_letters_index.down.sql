@@ -0,0 +1,3 @@
+BEGIN;
+DROP INDEX hook_schedules_dead_letters_idx;
+COMMIT;
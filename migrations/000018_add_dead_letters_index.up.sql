@@ -0,0 +1,3 @@
+BEGIN;
+CREATE INDEX hook_schedules_dead_letters_idx ON hook_schedules ((COALESCE(updated_at, created_at)), id) WHERE status IN ('failed', 'quarantined');
+COMMIT;
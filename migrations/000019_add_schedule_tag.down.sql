@@ -0,0 +1,4 @@
+BEGIN;
+DROP INDEX hook_schedules_tag_idx;
+ALTER TABLE hook_schedules DROP tag;
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+ALTER TABLE hook_schedules ADD tag VARCHAR(255);
+UPDATE hook_schedules s SET tag = c.tag FROM hook_configurations c WHERE c.id = s.hook_configuration_id;
+ALTER TABLE hook_schedules ALTER COLUMN tag SET NOT NULL;
+CREATE INDEX hook_schedules_tag_idx ON hook_schedules (tag);
+COMMIT;
@@ -5,6 +5,10 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/devmalloni/nautilus/x"
 )
@@ -13,21 +17,58 @@ const (
 	Global HookConfigurationTag = "global"
 )
 
+var (
+	ErrScheduleAlreadyExists = errors.New("there already is a schedule with this ID")
+	ErrCoalescingInTx        = errors.New("coalescing keys are not supported within a caller transaction")
+)
+
 type (
 	NautilusScheduler interface {
 		Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error)
 	}
+
+	// NotifiableScheduler is notified by Nautilus of the schedules written, so they are
+	// dispatched without waiting for a poll.
+	NotifiableScheduler interface {
+		Notify(schedules ...*HookSchedule)
+	}
+
+	// CapacityAwareScheduler is given by Run how many schedules its workers can take right
+	// away, so it does not claim schedules that would wait in line while their lease runs out.
+	CapacityAwareScheduler interface {
+		SetCapacity(capacity func() int)
+	}
 	Nautilus struct {
 		jsonSchemaValidator JSchemaValidator
 		persister           NautilusPersister
 		httpClient          *http.Client
-		workersCount        int
+		minWorkersCount     int
+		maxWorkersCount     int
+		workerScaleInterval time.Duration
+		workerPools         []WorkerPool
 		scheduleBufferSize  int
 		scheduler           NautilusScheduler
 		errCh               chan<- error
+		recurringInterval   time.Duration
+		inFlightLease       time.Duration
+		retryInterval       time.Duration
+		quarantineThreshold int
+		payloadGenerators   map[string]PayloadGenerator
+		payloadMergers      map[string]PayloadMerger
+		batchLocks          sync.Map
+		tagFallback         TagFallback
+
+		runLock sync.Mutex
+		running *runState
 	}
+
+	// TagFallback returns the tags tried in order when a tag has no configuration
+	// of a definition, e.g. tenant -> region -> global.
+	TagFallback func(tag HookConfigurationTag) []HookConfigurationTag
 )
 
+// Run dispatches schedules to the workers until ctx is done or Shutdown is called,
+// and returns once the workers finished.
 func (p *Nautilus) Run(ctx context.Context) {
 	reportError := func(errCh chan<- error, err error) {
 		if errCh != nil {
@@ -35,24 +76,119 @@ func (p *Nautilus) Run(ctx context.Context) {
 		}
 	}
 
-	worker := func(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	// executions outlive the run context on Shutdown, so in-flight deliveries can finish
+	runCtx, cancelRun := context.WithCancel(ctx)
+	execCtx, cancelExec := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelRun()
+	defer cancelExec()
+
+	// schedules are buffered in a priority queue per worker pool instead of the channel,
+	// so workers always pick the highest priority schedule available
+	scheduleCh := make(chan *HookSchedule)
+	dispatched := newInFlightRegistry(p.inFlightLease)
+	state := newRunState(cancelRun, cancelExec)
+
+	p.runLock.Lock()
+	pools := p.newRunPools(func(schedule *HookSchedule) {
+		state.start(schedule)
+		err := p.executeRecovered(execCtx, schedule)
+		state.finish(schedule)
+		dispatched.release(schedule.ID)
+		if err != nil {
+			reportError(p.errCh, err)
+		}
+	})
+	for _, pool := range pools {
+		state.queues = append(state.queues, pool.queue)
+	}
+	state.pool = pools[0].workers
+	p.running = state
+	p.runLock.Unlock()
+
+	defer func() {
+		p.runLock.Lock()
+		p.running = nil
+		p.runLock.Unlock()
+		close(state.done)
+	}()
+
+	go func() {
+		// claims are released even once the run is stopping, so other instances claim the
+		// schedules on their next poll instead of once the lease is over
+		releaseCtx := context.WithoutCancel(runCtx)
+		drop := func(schedule *HookSchedule) {
+			dispatched.release(schedule.ID)
+			state.drop(schedule)
+			if err := p.releaseClaim(releaseCtx, schedule); err != nil {
+				reportError(p.errCh, err)
+			}
+		}
+
 		for schedule := range scheduleCh {
-			err := p.executeSchedule(ctx, schedule.ID)
-			if err != nil {
-				reportError(errCh, err)
+			// still queued or running since an earlier poll
+			if !dispatched.acquire(schedule.ID, time.Now()) {
+				continue
+			}
+
+			// the run is stopping, so the schedule is abandoned instead of queued
+			if runCtx.Err() != nil {
+				drop(schedule)
+				continue
+			}
+
+			// a full pool does not hold back the others, its schedule is dispatched again later
+			pool := routeSchedule(pools, schedule)
+			if !pool.queue.TryPush(schedule) {
+				// closed by Shutdown draining the queues
+				if runCtx.Err() != nil {
+					drop(schedule)
+					continue
+				}
+
+				dispatched.release(schedule.ID)
+				if err := p.deferDispatch(releaseCtx, schedule); err != nil {
+					reportError(p.errCh, err)
+				}
 			}
 		}
+
+		for _, pool := range pools {
+			pool.queue.Close()
+		}
+		close(state.dispatched)
+	}()
+
+	// start workers, only the default pool being scaled
+	for _, pool := range pools {
+		pool.workers.start()
 	}
+	scaleCtx, stopScaling := context.WithCancel(runCtx)
+	go pools[0].workers.autoscale(scaleCtx, p.workerScaleInterval)
 
-	scheduleCh := make(chan *HookSchedule, p.scheduleBufferSize)
-	defer close(scheduleCh)
+	go p.runRecurringSchedules(runCtx, p.errCh)
 
-	// start workers
-	for i := 0; i < p.workersCount; i++ {
-		go worker(ctx, scheduleCh, p.errCh)
+	if scheduler, ok := p.scheduler.(CapacityAwareScheduler); ok {
+		scheduler.SetCapacity(func() int {
+			capacity := 0
+			for _, pool := range pools {
+				capacity += pool.workers.capacity()
+			}
+			return capacity
+		})
 	}
 
-	p.scheduler.Start(ctx, scheduleCh, p.errCh)
+	p.scheduler.Start(runCtx, scheduleCh, p.errCh)
+	close(scheduleCh)
+
+	// without Shutdown, in-flight executions are cancelled along with ctx as before
+	if ctx.Err() != nil {
+		cancelExec()
+	}
+
+	stopScaling()
+	for _, pool := range pools {
+		pool.workers.stop()
+	}
 }
 
 // TrySchedule is a convenience method that checks if a hook configuration exists
@@ -61,8 +197,9 @@ func (p *Nautilus) TrySchedule(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload json.RawMessage) error {
-	_, err := p.persister.FindHookConfiguration(ctx, hookDefinitionID, tag)
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) error {
+	_, err := p.ResolveHookConfigurations(ctx, hookDefinitionID, tag)
 	if err == ErrNotFound {
 		return nil
 	}
@@ -71,7 +208,7 @@ func (p *Nautilus) TrySchedule(ctx context.Context,
 		return err
 	}
 
-	_, err = p.ScheduleJSON(ctx, id, hookDefinitionID, tag, payload)
+	_, err = p.ScheduleJSON(ctx, id, hookDefinitionID, tag, payload, options...)
 	if err != nil {
 		return err
 	}
@@ -83,21 +220,23 @@ func (p *Nautilus) TryScheduleJSON(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload any) error {
+	payload any,
+	options ...func(*HookSchedule)) error {
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return err
 	}
 
-	return p.TrySchedule(ctx, id, hookDefinitionID, tag, jsonPayload)
+	return p.TrySchedule(ctx, id, hookDefinitionID, tag, jsonPayload, options...)
 }
 
 func (p *Nautilus) MustScheduleJSON(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload any) *HookSchedule {
-	schedule, err := p.ScheduleJSON(ctx, id, hookDefinitionID, tag, payload)
+	payload any,
+	options ...func(*HookSchedule)) *HookSchedule {
+	schedule, err := p.ScheduleJSON(ctx, id, hookDefinitionID, tag, payload, options...)
 	if err != nil {
 		panic(err)
 	}
@@ -109,21 +248,23 @@ func (p *Nautilus) ScheduleJSON(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload any) (*HookSchedule, error) {
+	payload any,
+	options ...func(*HookSchedule)) (*HookSchedule, error) {
 	jsonPayload, err := json.Marshal(payload)
 	if err != nil {
 		return nil, err
 	}
 
-	return p.Schedule(ctx, id, hookDefinitionID, tag, jsonPayload)
+	return p.Schedule(ctx, id, hookDefinitionID, tag, jsonPayload, options...)
 }
 
 func (p *Nautilus) MustSchedule(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload json.RawMessage) *HookSchedule {
-	schedule, err := p.Schedule(ctx, id, hookDefinitionID, tag, payload)
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) *HookSchedule {
+	schedule, err := p.Schedule(ctx, id, hookDefinitionID, tag, payload, options...)
 	if err != nil {
 		panic(err)
 	}
@@ -131,80 +272,629 @@ func (p *Nautilus) MustSchedule(ctx context.Context,
 	return schedule
 }
 
+// Schedule works as ScheduleAll, returning the schedule of the first configuration.
+// Use ScheduleAll to get every schedule when the tag has several configurations.
 func (p *Nautilus) Schedule(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload json.RawMessage) (*HookSchedule, error) {
-	configuration, err := p.persister.FindHookConfiguration(ctx, hookDefinitionID, tag)
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) (*HookSchedule, error) {
+	schedules, err := p.ScheduleAll(ctx, id, hookDefinitionID, tag, payload, options...)
 	if err != nil {
 		return nil, err
 	}
 
-	var scheduleID string
-	if id != nil {
-		schedule, _, err := p.FindScheduleByID(ctx, *id)
-		if err != nil && err != ErrNotFound {
+	return schedules[0], nil
+}
+
+func (p *Nautilus) MustScheduleAll(ctx context.Context,
+	id *string,
+	hookDefinitionID string,
+	tag HookConfigurationTag,
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) []*HookSchedule {
+	schedules, err := p.ScheduleAll(ctx, id, hookDefinitionID, tag, payload, options...)
+	if err != nil {
+		panic(err)
+	}
+
+	return schedules
+}
+
+func (p *Nautilus) ScheduleAllJSON(ctx context.Context,
+	id *string,
+	hookDefinitionID string,
+	tag HookConfigurationTag,
+	payload any,
+	options ...func(*HookSchedule)) ([]*HookSchedule, error) {
+	jsonPayload, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ScheduleAll(ctx, id, hookDefinitionID, tag, jsonPayload, options...)
+}
+
+// ScheduleAll creates one schedule for every active configuration of the definition and
+// tag whose filter matches the payload. Configurations filtering the payload out are
+// skipped, and ErrPayloadFiltered is returned if no configuration matches. If id is set,
+// it is the ID of the schedule when a single configuration is resolved, and the ID of each
+// schedule is the given id suffixed with ":" and the configuration ID otherwise, see ScheduleID.
+// Payloads duplicating a recent schedule of a configuration are dropped for it, so only the
+// schedules of the other configurations are returned, and a *DuplicatePayloadError is returned
+// when the payload was dropped for every configuration. ScheduleMany reports the dropped ones.
+func (p *Nautilus) ScheduleAll(ctx context.Context,
+	id *string,
+	hookDefinitionID string,
+	tag HookConfigurationTag,
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) ([]*HookSchedule, error) {
+	schedules, err := p.createSchedules(ctx, id, hookDefinitionID, tag, payload, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	// the idempotency key supersedes the ID check, so retries get the original schedules back
+	if schedules[0].IdempotencyKey != nil {
+		schedules, err = p.persister.WriteIdempotentHookSchedules(ctx, schedules)
+		if err != nil {
 			return nil, err
 		}
-		if schedule != nil {
-			return nil, errors.New("there already is a schedule with this ID")
+
+		p.notifyScheduler(schedules...)
+
+		return schedules, nil
+	}
+
+	err = p.checkScheduleIDs(ctx, id, schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := newScheduleBatch()
+	schedules, originals, err := p.deduplicateSchedules(ctx, schedules, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	err = duplicatePayloadError(schedules, originals)
+	if err != nil {
+		return nil, err
+	}
+
+	schedules, pending, err := p.coalesceSchedules(ctx, schedules, batch)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pending) > 0 {
+		err = p.persister.WriteHookSchedules(ctx, pending)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	p.notifyScheduler(schedules...)
+
+	return schedules, nil
+}
+
+// ScheduleTx works as ScheduleAll, but writes the schedules within the caller transaction
+// (*sql.Tx or *sqlx.Tx), so they are committed or rolled back along with the caller data.
+// It requires a persister implementing HookScheduleTxWriter, such as SqlPersister.
+// A reused idempotency key fails on the database unique index instead of returning the
+// original schedules. Payloads are deduplicated against the committed schedules only, and
+// coalescing keys of debounced definitions fail with ErrCoalescingInTx, as the pending schedule
+// would be replaced whether or not the transaction is committed.
+func (p *Nautilus) ScheduleTx(ctx context.Context,
+	tx SqlTx,
+	id *string,
+	hookDefinitionID string,
+	tag HookConfigurationTag,
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) ([]*HookSchedule, error) {
+	txWriter, ok := p.persister.(HookScheduleTxWriter)
+	if !ok {
+		return nil, ErrTxNotSupported
+	}
+
+	schedules, err := p.createSchedules(ctx, id, hookDefinitionID, tag, payload, options...)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, schedule := range schedules {
+		if schedule.CoalescingKey != nil && schedule.HookConfiguration.HookDefinition.DebounceWindow > 0 {
+			return nil, ErrCoalescingInTx
 		}
-		scheduleID = *id
-	} else {
-		scheduleID = x.NewUUIDStr()
 	}
 
-	schedule, err := configuration.Schedule(scheduleID, payload, p.jsonSchemaValidator)
+	err = p.checkScheduleIDs(ctx, id, schedules)
 	if err != nil {
 		return nil, err
 	}
 
-	err = p.persister.WriteHookSchedule(ctx, schedule)
+	schedules, originals, err := p.deduplicateSchedules(ctx, schedules, newScheduleBatch())
 	if err != nil {
 		return nil, err
 	}
 
-	return schedule, nil
+	err = duplicatePayloadError(schedules, originals)
+	if err != nil {
+		return nil, err
+	}
+
+	err = txWriter.WriteHookSchedulesTx(ctx, tx, schedules)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedules, nil
 }
 
-func (p *Nautilus) ScheduleAndExecute(ctx context.Context,
+func (p *Nautilus) createSchedules(ctx context.Context,
 	id *string,
 	hookDefinitionID string,
 	tag HookConfigurationTag,
-	payload json.RawMessage) error {
-	schedule, err := p.Schedule(ctx, id, hookDefinitionID, tag, payload)
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) ([]*HookSchedule, error) {
+	configurations, err := p.ResolveHookConfigurations(ctx, hookDefinitionID, tag)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = p.executeSchedule(ctx, schedule.ID)
+	return p.buildSchedules(configurations, id, tag, payload, options...)
+}
+
+// checkScheduleIDs fails with ErrScheduleAlreadyExists when the caller provided ID is taken,
+// either by itself or as the ID derived for one of the schedules.
+func (p *Nautilus) checkScheduleIDs(ctx context.Context, id *string, schedules []*HookSchedule) error {
+	if id == nil {
+		return nil
+	}
+
+	ids := []string{*id}
+	for i := range schedules {
+		if schedules[i].ID != *id {
+			ids = append(ids, schedules[i].ID)
+		}
+	}
+
+	for i := range ids {
+		schedule, _, err := p.FindScheduleByID(ctx, ids[i])
+		if err != nil && err != ErrNotFound {
+			return err
+		}
+		if schedule != nil {
+			return ErrScheduleAlreadyExists
+		}
+	}
+
+	return nil
+}
+
+// buildSchedules creates the schedules of payload to the configurations resolved for tag,
+// without writing them.
+func (p *Nautilus) buildSchedules(configurations []*HookConfiguration,
+	id *string,
+	tag HookConfigurationTag,
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) ([]*HookSchedule, error) {
+	var schedules []*HookSchedule
+	for _, configuration := range configurations {
+		scheduleID := x.NewUUIDStr()
+		if id != nil {
+			scheduleID = *id
+			if len(configurations) > 1 {
+				scheduleID = ScheduleID(*id, configuration.ID)
+			}
+		}
+
+		schedule, err := configuration.Schedule(scheduleID, payload, p.jsonSchemaValidator)
+		if err == ErrPayloadFiltered {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		schedule.Tag = tag
+
+		for i := range options {
+			options[i](schedule)
+		}
+
+		schedules = append(schedules, schedule)
+	}
+
+	// every configuration filtered the payload out
+	if len(schedules) == 0 {
+		return nil, ErrPayloadFiltered
+	}
+
+	return schedules, nil
+}
+
+func (p *Nautilus) ScheduleAndExecute(ctx context.Context,
+	id *string,
+	hookDefinitionID string,
+	tag HookConfigurationTag,
+	payload json.RawMessage,
+	options ...func(*HookSchedule)) error {
+	schedules, err := p.ScheduleAll(ctx, id, hookDefinitionID, tag, payload, options...)
 	if err != nil {
 		return err
 	}
 
+	for i := range schedules {
+		err = p.executeSchedule(ctx, schedules[i].ID)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
+// Broadcast schedules the payload to every configuration of the definition, or only to
+// the configurations of the given tags, in a single write. It returns the group ID
+// shared by the created schedules, which can be used with FindGroupStatus. Configurations
+// the payload duplicates a recent schedule of are skipped as in ScheduleAll.
+func (p *Nautilus) Broadcast(ctx context.Context,
+	hookDefinitionID string,
+	payload json.RawMessage,
+	tags ...HookConfigurationTag) (string, error) {
+	definition, err := p.persister.FindHookDefinitionByID(ctx, hookDefinitionID)
+	if err != nil {
+		return "", err
+	}
+
+	configurations, err := p.persister.FindHookConfigurationsByDefinitionID(ctx, hookDefinitionID)
+	if err != nil {
+		return "", err
+	}
+
+	groupID := x.NewUUIDStr()
+	var schedules []*HookSchedule
+	for i := range configurations {
+		if configurations[i].Disabled || (len(tags) > 0 && !slices.Contains(tags, configurations[i].Tag)) {
+			continue
+		}
+		configurations[i].HookDefinition = definition
+
+		schedule, err := configurations[i].Schedule(x.NewUUIDStr(), payload, p.jsonSchemaValidator)
+		if err == ErrPayloadFiltered {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		schedule.GroupID = &groupID
+
+		schedules = append(schedules, schedule)
+	}
+
+	if len(schedules) == 0 {
+		return "", ErrNotFound
+	}
+
+	schedules, originals, err := p.deduplicateSchedules(ctx, schedules, newScheduleBatch())
+	if err != nil {
+		return "", err
+	}
+
+	err = duplicatePayloadError(schedules, originals)
+	if err != nil {
+		return "", err
+	}
+
+	err = p.persister.WriteHookSchedules(ctx, schedules)
+	if err != nil {
+		return "", err
+	}
+
+	p.notifyScheduler(schedules...)
+
+	return groupID, nil
+}
+
+// FindGroupStatus aggregates the delivery status of the schedules created by a Broadcast.
+func (p *Nautilus) FindGroupStatus(ctx context.Context, groupID string) (*HookScheduleGroupStatus, error) {
+	schedules, err := p.persister.FindHookSchedulesByGroupID(ctx, groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(schedules) == 0 {
+		return nil, ErrNotFound
+	}
+
+	status := &HookScheduleGroupStatus{
+		GroupID:  groupID,
+		Total:    len(schedules),
+		Statuses: make(map[HookScheduleStatus]int),
+	}
+	for i := range schedules {
+		status.Statuses[schedules[i].Status]++
+	}
+
+	return status, nil
+}
+
 func (p *Nautilus) executeSchedule(ctx context.Context, scheduleID string) error {
+	return p.executeDispatched(ctx, scheduleID, nil)
+}
+
+// executeDispatched executes the schedule under a claim of its own when the persister is a
+// HookScheduleClaimer, handed over from the claim it was dispatched with, if any. The claim
+// keeps its payload from being replaced while it is delivered, and other executions of the
+// schedule from delivering it too.
+func (p *Nautilus) executeDispatched(ctx context.Context, scheduleID string, heldBy *string) error {
 	schedule, _, err := p.FindScheduleByID(ctx, scheduleID)
 	if err != nil {
 		return err
 	}
 
+	// resolved since it was dispatched
+	if schedule.Status != HookScheduleStatusScheduled {
+		return nil
+	}
+
+	if claimer, ok := p.persister.(HookScheduleClaimer); ok {
+		claimed, err := claimer.ClaimHookSchedules(ctx, []string{scheduleID}, x.NewUUIDStr(), heldBy, p.inFlightLease)
+		if err != nil {
+			return err
+		}
+
+		// resolved or being delivered elsewhere
+		if len(claimed) == 0 {
+			return nil
+		}
+
+		claimed[0].HookConfiguration = schedule.HookConfiguration
+		schedule = claimed[0]
+	}
+
+	// it will be dispatched again once the debounce window is over
+	if schedule.IsDebouncing(time.Now()) {
+		p.notifyScheduler(schedule)
+		return p.releaseClaim(ctx, schedule)
+	}
+
+	blocked, err := p.isBlocked(ctx, schedule)
+	if err != nil {
+		return err
+	}
+
+	// it will be dispatched again once its predecessors are resolved
+	if blocked {
+		return p.backOff(ctx, schedule)
+	}
+
+	if schedule.HookConfiguration.HookDefinition.IsBatched() {
+		return p.executeBatch(ctx, schedule)
+	}
+
 	execution, err := schedule.Execute(ctx, x.NewUUIDStr(), p.httpClient)
 	if err != nil {
 		return err
 	}
+	p.scheduleRetry(schedule)
 
 	err = p.persister.WriteHookSchedule(ctx, schedule, execution)
 	if err != nil {
 		return err
 	}
 
+	// retried by the scheduler unless the attempt resolved it
+	p.notifyScheduler(schedule)
+
+	return nil
+}
+
+// scheduleRetry sets when schedules left scheduled by their attempt are due again.
+func (p *Nautilus) scheduleRetry(schedules ...*HookSchedule) {
+	for _, schedule := range schedules {
+		if schedule.Status != HookScheduleStatusScheduled || schedule.UpdatedAt == nil {
+			continue
+		}
+		schedule.NextAttemptAt = x.NilTime(schedule.UpdatedAt.Add(p.retryInterval))
+	}
+}
+
+func (p *Nautilus) notifyScheduler(schedules ...*HookSchedule) {
+	if scheduler, ok := p.scheduler.(NotifiableScheduler); ok {
+		scheduler.Notify(schedules...)
+	}
+}
+
+// backOff delays the next attempt of a schedule skipped without an attempt by the retry
+// interval, so it is not read again on every poll until it can be delivered.
+func (p *Nautilus) backOff(ctx context.Context, schedule *HookSchedule) error {
+	schedule.NextAttemptAt = x.NilTime(time.Now().UTC().Add(p.retryInterval))
+
+	err := p.persister.WriteHookSchedule(ctx, schedule)
+	if err != nil {
+		return err
+	}
+
+	p.notifyScheduler(schedule)
+
 	return nil
 }
 
+// releaseClaim lets schedules skipped without an attempt be claimed again on the next poll,
+// instead of waiting for the claim lease to be over.
+func (p *Nautilus) releaseClaim(ctx context.Context, schedule *HookSchedule) error {
+	claimer, ok := p.persister.(HookScheduleClaimer)
+	if !ok || schedule.ClaimedBy == nil {
+		return nil
+	}
+
+	return claimer.ReleaseHookScheduleClaim(ctx, schedule.ID)
+}
+
+// deferDispatch releases the claim of a schedule whose pool is full, backing it off like a
+// blocked schedule, so the next claims reach the schedules of the other pools instead of
+// claiming it again while its pool is still full.
+func (p *Nautilus) deferDispatch(ctx context.Context, schedule *HookSchedule) error {
+	if _, ok := p.persister.(HookScheduleClaimer); !ok || schedule.ClaimedBy == nil {
+		return nil
+	}
+
+	return p.backOff(ctx, schedule)
+}
+
+// batchCandidatesLimit bounds the pending schedules read to fill a batch, as the ones that
+// could not be delivered alone are left out.
+const batchCandidatesLimit = 1000
+
+// executeBatch delivers the pending schedules of the configuration of schedule in a single
+// request, once the batch is full or its oldest schedule waited for BatchMaxWait. Members are
+// checked like schedules delivered alone, and claimed for the batch when the persister is a
+// HookScheduleClaimer, so instances do not deliver the same schedules in their batches.
+func (p *Nautilus) executeBatch(ctx context.Context, schedule *HookSchedule) error {
+	claimer, ok := p.persister.(HookScheduleClaimer)
+	if !ok {
+		l, _ := p.batchLocks.LoadOrStore(schedule.HookConfigurationID, &sync.Mutex{})
+		l.(*sync.Mutex).Lock()
+		defer l.(*sync.Mutex).Unlock()
+	}
+
+	definition := schedule.HookConfiguration.HookDefinition
+	schedules, err := p.batchMembers(ctx, schedule)
+	if err != nil {
+		return err
+	}
+
+	if len(schedules) < definition.BatchMaxSize && time.Since(schedules[0].CreatedAt) < definition.BatchMaxWait {
+		return p.waitForBatch(ctx, schedule, schedules[0].CreatedAt.Add(definition.BatchMaxWait))
+	}
+
+	if claimer != nil {
+		ids := make([]string, len(schedules))
+		for i := range schedules {
+			ids[i] = schedules[i].ID
+		}
+
+		// the schedule is handed over from the claim it was dispatched with
+		schedules, err = claimer.ClaimHookSchedules(ctx, ids, x.NewUUIDStr(), schedule.ClaimedBy, p.inFlightLease)
+		if err != nil {
+			return err
+		}
+
+		// already delivered within another batch
+		if !slices.ContainsFunc(schedules, func(s *HookSchedule) bool { return s.ID == schedule.ID }) {
+			return p.releaseClaims(ctx, claimer, schedules)
+		}
+
+		sort.Slice(schedules, func(i, j int) bool {
+			if schedules[i].CreatedAt.Equal(schedules[j].CreatedAt) {
+				return schedules[i].ID < schedules[j].ID
+			}
+			return schedules[i].CreatedAt.Before(schedules[j].CreatedAt)
+		})
+	}
+
+	for i := range schedules {
+		schedules[i].HookConfiguration = schedule.HookConfiguration
+	}
+
+	executions, err := ExecuteBatch(ctx, x.NewUUIDStr(), schedules, p.httpClient)
+	if err != nil {
+		return err
+	}
+	p.scheduleRetry(schedules...)
+
+	return p.persister.WriteHookSchedules(ctx, schedules, executions...)
+}
+
+// waitForBatch releases the claim of a schedule whose batch is still waiting for members, and
+// notifies the scheduler to dispatch it again once the batch waited for BatchMaxWait. Its next
+// attempt is left as is, so it is still a member of the batches delivered meanwhile.
+func (p *Nautilus) waitForBatch(ctx context.Context, schedule *HookSchedule, at time.Time) error {
+	err := p.releaseClaim(ctx, schedule)
+	if err != nil {
+		return err
+	}
+
+	waiting := *schedule
+	waiting.NextAttemptAt = &at
+	waiting.ClaimedBy, waiting.ClaimedUntil = nil, nil
+	p.notifyScheduler(&waiting)
+
+	return nil
+}
+
+// batchMembers returns schedule and the oldest pending schedules of its configuration that
+// could be delivered alone, up to BatchMaxSize.
+func (p *Nautilus) batchMembers(ctx context.Context, schedule *HookSchedule) ([]*HookSchedule, error) {
+	definition := schedule.HookConfiguration.HookDefinition
+	candidates, err := p.persister.FindScheduledHookSchedulesOfConfiguration(ctx, schedule.HookConfigurationID, batchCandidatesLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	res := []*HookSchedule{schedule}
+	for _, candidate := range candidates {
+		if len(res) >= definition.BatchMaxSize {
+			break
+		}
+
+		candidate.HookConfiguration = schedule.HookConfiguration
+		if candidate.ID == schedule.ID || candidate.NextAttempt().After(now) || candidate.IsDebouncing(now) {
+			continue
+		}
+
+		blocked, err := p.isBlocked(ctx, candidate)
+		if err != nil {
+			return nil, err
+		}
+
+		if !blocked {
+			res = append(res, candidate)
+		}
+	}
+
+	sort.SliceStable(res, func(i, j int) bool { return res[i].CreatedAt.Before(res[j].CreatedAt) })
+
+	return res, nil
+}
+
+func (p *Nautilus) releaseClaims(ctx context.Context, claimer HookScheduleClaimer, schedules []*HookSchedule) error {
+	for _, schedule := range schedules {
+		err := claimer.ReleaseHookScheduleClaim(ctx, schedule.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// isBlocked reports whether an earlier schedule with the same ordering key
+// must be resolved before schedule can be delivered.
+func (p *Nautilus) isBlocked(ctx context.Context, schedule *HookSchedule) (bool, error) {
+	if schedule.OrderingKey == nil {
+		return false, nil
+	}
+
+	predecessors, err := p.persister.FindHookSchedulePredecessors(ctx, schedule)
+	if err != nil {
+		return false, err
+	}
+
+	policy := schedule.HookConfiguration.HookDefinition.OrderingFailurePolicy
+	for i := range predecessors {
+		if schedule.IsBlockedBy(predecessors[i], policy) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
 // To User
 func (p *Nautilus) RetryScheduleByID(ctx context.Context, scheduleID string) error {
 	schedule, _, err := p.persister.FindHookSchedulesByID(ctx, scheduleID)
@@ -228,6 +918,38 @@ func (p *Nautilus) FindScheduleByID(ctx context.Context, scheduleID string) (*Ho
 	return p.persister.FindHookSchedulesByID(ctx, scheduleID)
 }
 
+// ResolveHookConfigurations returns the active configurations of the definition and tag,
+// including the ones of subscriptions of the tag matching the definition. If there are
+// none, the tags of the fallback chain are tried in order.
+func (p *Nautilus) ResolveHookConfigurations(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) ([]*HookConfiguration, error) {
+	tags := []HookConfigurationTag{tag}
+	if p.tagFallback != nil {
+		for _, fallback := range p.tagFallback(tag) {
+			if !slices.Contains(tags, fallback) {
+				tags = append(tags, fallback)
+			}
+		}
+	}
+
+	for _, tag := range tags {
+		configurations, err := p.persister.FindActiveHookConfigurations(ctx, hookDefinitionID, tag)
+		if err == ErrNotFound {
+			continue
+		}
+
+		return configurations, err
+	}
+
+	return nil, ErrNotFound
+}
+
+// FallbackTo returns a TagFallback with the same fallback chain for every tag.
+func FallbackTo(tags ...HookConfigurationTag) TagFallback {
+	return func(HookConfigurationTag) []HookConfigurationTag {
+		return tags
+	}
+}
+
 func (p *Nautilus) ListConfigurationsOfTag(ctx context.Context, tag HookConfigurationTag) ([]*HookConfiguration, error) {
 	return p.persister.FindHookConfigurationsByTag(ctx, tag)
 }
@@ -299,11 +1021,55 @@ func (p *Nautilus) RegisterDefinitions(ctx context.Context, definitions ...*Hook
 		if err != nil {
 			return err
 		}
+
+		err = p.reconcileSubscriptions(ctx, definitions[i])
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
+// WithOrderingKey makes the schedule be delivered strictly after every earlier schedule
+// of the same configuration sharing the ordering key.
+func WithOrderingKey(orderingKey string) func(*HookSchedule) {
+	return func(s *HookSchedule) {
+		s.OrderingKey = &orderingKey
+	}
+}
+
+// WithIdempotencyKey makes scheduling again with the same key return the schedules
+// originally created for it, as long as the payload is the same. A different payload
+// fails with an *IdempotencyConflictError.
+func WithIdempotencyKey(idempotencyKey string) func(*HookSchedule) {
+	return func(s *HookSchedule) {
+		s.IdempotencyKey = &idempotencyKey
+	}
+}
+
+// WithCoalescingKey makes the schedule, within the debounce window of its definition,
+// replace the payload of a pending schedule of the same configuration and key.
+func WithCoalescingKey(coalescingKey string) func(*HookSchedule) {
+	return func(s *HookSchedule) {
+		s.CoalescingKey = &coalescingKey
+	}
+}
+
+// WithPriority overrides the priority inherited from the hook definition.
+func WithPriority(priority int) func(*HookSchedule) {
+	return func(s *HookSchedule) {
+		s.Priority = priority
+	}
+}
+
+// ScheduleID returns the ID of the schedule created for the configuration when scheduling
+// with the given id to more than one configuration, e.g to find it with FindScheduleByID.
+// Scheduling to a single configuration keeps the given id.
+func ScheduleID(id string, hookConfigurationID string) string {
+	return id + ":" + hookConfigurationID
+}
+
 func ID(id string) *string {
 	if id == "" {
 		uid := x.NewUUIDStr()
@@ -0,0 +1,168 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+)
+
+type (
+	ScheduleRequest struct {
+		ID               *string
+		HookDefinitionID string
+		Tag              HookConfigurationTag
+		Payload          json.RawMessage
+		Options          []func(*HookSchedule)
+	}
+
+	// ScheduleResult holds the schedules created for a ScheduleRequest or
+	// the error that prevented the request from being scheduled.
+	ScheduleResult struct {
+		Schedules []*HookSchedule
+		// Duplicates are the recent schedules the payload duplicates, for the configurations
+		// it was dropped for. Err is a *DuplicatePayloadError when it was dropped for all of them.
+		Duplicates []*HookSchedule
+		Err        error
+	}
+)
+
+// ScheduleMany schedules every request resolving each definition and tag pair once and
+// writing all the schedules at once. Results are returned in the same order as requests.
+// Requests failing validation do not prevent the others from being scheduled, while
+// an error writing the schedules is returned and nothing is scheduled. Requests are
+// deduplicated and coalesced as by ScheduleAll, in order, including against the earlier
+// requests of the call. As by ScheduleAll, requests with an idempotency key are neither
+// deduplicated nor coalesced, and a key reused with a different payload fails the whole
+// call with an *IdempotencyConflictError.
+func (p *Nautilus) ScheduleMany(ctx context.Context, requests []ScheduleRequest) ([]ScheduleResult, error) {
+	type configurationsKey struct {
+		hookDefinitionID string
+		tag              HookConfigurationTag
+	}
+	type configurationsResult struct {
+		configurations []*HookConfiguration
+		err            error
+	}
+
+	results := make([]ScheduleResult, len(requests))
+	resolved := make(map[configurationsKey]configurationsResult)
+	var ids []string
+	idempotent := false
+	for i, r := range requests {
+		key := configurationsKey{r.HookDefinitionID, r.Tag}
+		c, ok := resolved[key]
+		if !ok {
+			c.configurations, c.err = p.ResolveHookConfigurations(ctx, r.HookDefinitionID, r.Tag)
+			if c.err != nil && c.err != ErrNotFound && c.err != ErrPayloadFiltered {
+				return nil, c.err
+			}
+			resolved[key] = c
+		}
+
+		if c.err != nil {
+			results[i].Err = c.err
+			continue
+		}
+
+		results[i].Schedules, results[i].Err = p.buildSchedules(c.configurations, r.ID, r.Tag, r.Payload, r.Options...)
+		if results[i].Err != nil {
+			continue
+		}
+
+		if results[i].Schedules[0].IdempotencyKey != nil {
+			idempotent = true
+			continue
+		}
+
+		if r.ID == nil {
+			continue
+		}
+
+		for _, schedule := range results[i].Schedules {
+			if slices.Contains(ids, schedule.ID) {
+				results[i].Schedules, results[i].Err = nil, ErrScheduleAlreadyExists
+				break
+			}
+		}
+		for _, schedule := range results[i].Schedules {
+			ids = append(ids, schedule.ID)
+		}
+	}
+
+	existingIDs, err := p.persister.FindExistingHookScheduleIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// the schedules to write, along with their position in results
+	type slot struct{ result, schedule int }
+	var schedules []*HookSchedule
+	var slots []slot
+	batch := newScheduleBatch()
+	for i := range results {
+		for _, schedule := range results[i].Schedules {
+			if slices.Contains(existingIDs, schedule.ID) {
+				results[i].Schedules, results[i].Err = nil, ErrScheduleAlreadyExists
+				break
+			}
+		}
+
+		if len(results[i].Schedules) == 0 {
+			continue
+		}
+
+		if results[i].Schedules[0].IdempotencyKey != nil {
+			for j := range results[i].Schedules {
+				schedules = append(schedules, results[i].Schedules[j])
+				slots = append(slots, slot{i, j})
+			}
+			continue
+		}
+
+		results[i].Schedules, results[i].Duplicates, err = p.deduplicateSchedules(ctx, results[i].Schedules, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i].Err = duplicatePayloadError(results[i].Schedules, results[i].Duplicates)
+
+		var pending []*HookSchedule
+		results[i].Schedules, pending, err = p.coalesceSchedules(ctx, results[i].Schedules, batch)
+		if err != nil {
+			return nil, err
+		}
+
+		for j := range results[i].Schedules {
+			if slices.Contains(pending, results[i].Schedules[j]) {
+				schedules = append(schedules, results[i].Schedules[j])
+				slots = append(slots, slot{i, j})
+			}
+		}
+	}
+
+	if len(schedules) > 0 && !idempotent {
+		err = p.persister.WriteHookSchedules(ctx, schedules)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(schedules) > 0 && idempotent {
+		// idempotent writes may hand back the schedules originally created for the keys
+		written, err := p.persister.WriteIdempotentHookSchedules(ctx, schedules)
+		if err != nil {
+			return nil, err
+		}
+
+		for k, s := range slots {
+			results[s.result].Schedules[s.schedule] = written[k]
+		}
+	}
+
+	// coalesced schedules are notified too, as their debounce window moved
+	for i := range results {
+		p.notifyScheduler(results[i].Schedules...)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,147 @@
+package nautilus
+
+import (
+	"context"
+	"errors"
+)
+
+var (
+	ErrNotDeadLetter = errors.New("schedule is not a dead letter")
+)
+
+// deadLetterPageSize bounds the dead letters requeued at once by RequeueDeadLetters.
+const deadLetterPageSize = 1000
+
+type (
+	// RequeueOption configures how Requeue and RequeueDeadLetters schedule dead letters again.
+	RequeueOption func(*requeueOptions)
+
+	requeueOptions struct {
+		url *string
+	}
+)
+
+// WithRequeueURL delivers a requeued dead letter to url, e.g once a broken endpoint moved.
+// Requeueing fails without requeueing anything when url is not an http or https URL.
+func WithRequeueURL(url string) RequeueOption {
+	return func(o *requeueOptions) {
+		o.url = &url
+	}
+}
+
+// ListDeadLetters returns the failed and quarantined schedules matching the filter, ordered
+// by the time they failed.
+func (p *Nautilus) ListDeadLetters(ctx context.Context, filter DeadLetterFilter) ([]*HookSchedule, error) {
+	return p.persister.FindDeadLetterHookSchedules(ctx, filter)
+}
+
+// Requeue schedules a dead letter again, resetting its attempts. Its previous executions are
+// kept, so the history of the schedule shows the failed attempts before the requeue.
+func (p *Nautilus) Requeue(ctx context.Context, id string, options ...RequeueOption) (*HookSchedule, error) {
+	o, err := newRequeueOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule, _, err := p.persister.FindHookSchedulesByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !schedule.IsDeadLetter() {
+		return nil, ErrNotDeadLetter
+	}
+
+	err = p.requeue(ctx, []*HookSchedule{schedule}, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// RequeueDeadLetters requeues the dead letters matching the filter like Requeue, and returns
+// them. An empty filter requeues every dead letter.
+func (p *Nautilus) RequeueDeadLetters(ctx context.Context, filter DeadLetterFilter, options ...RequeueOption) ([]*HookSchedule, error) {
+	o, err := newRequeueOptions(options)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*HookSchedule
+	for {
+		// requeued schedules are no longer dead letters, so each page starts over the filter
+		page := filter
+		page.Limit = deadLetterPageSize
+		if filter.Limit > 0 {
+			page.Limit = min(deadLetterPageSize, filter.Limit-len(res))
+		}
+
+		if page.Limit <= 0 {
+			return res, nil
+		}
+
+		schedules, err := p.persister.FindDeadLetterHookSchedules(ctx, page)
+		if err != nil {
+			return res, err
+		}
+
+		if len(schedules) == 0 {
+			return res, nil
+		}
+
+		err = p.requeue(ctx, schedules, o)
+		if err != nil {
+			return res, err
+		}
+		res = append(res, schedules...)
+
+		if len(schedules) < page.Limit {
+			return res, nil
+		}
+	}
+}
+
+func newRequeueOptions(options []RequeueOption) (*requeueOptions, error) {
+	o := &requeueOptions{}
+	for i := range options {
+		options[i](o)
+	}
+
+	if o.url != nil {
+		err := validateURL(*o.url)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+func (p *Nautilus) requeue(ctx context.Context, schedules []*HookSchedule, o *requeueOptions) error {
+	ids := make([]string, len(schedules))
+	for i, schedule := range schedules {
+		schedule.requeue()
+		if o.url != nil {
+			schedule.URL = *o.url
+		}
+		ids[i] = schedule.ID
+	}
+
+	// the URL is replaced before the schedules are requeued, so they are never delivered to the old one
+	if o.url != nil {
+		err := p.persister.ReplaceHookSchedulesURL(ctx, ids, *o.url)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := p.persister.WriteHookSchedules(ctx, schedules)
+	if err != nil {
+		return err
+	}
+
+	p.notifyScheduler(schedules...)
+
+	return nil
+}
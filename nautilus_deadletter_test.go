@@ -0,0 +1,158 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/devmalloni/nautilus/x"
+)
+
+func TestNautilus_DeadLetters(t *testing.T) {
+	ctx := context.Background()
+
+	persister := NewInMemoryPersister()
+	n := New(WithPersister(persister), WithTagFallback(FallbackTo(Global)))
+	err := n.RegisterDefinitions(ctx,
+		&HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1},
+		&HookDefinition{ID: "on_invoiced", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "tenant-1-created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "tenant-1"},
+		// tenant-2 is resolved to the global configuration
+		&HookConfiguration{ID: "global-created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: Global},
+		&HookConfiguration{ID: "tenant-1-invoiced", HookDefinitionID: "on_invoiced", URL: "http://crm/webhook", Tag: "tenant-1"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	// dead letters failed an hour apart, with one execution each
+	now := time.Now().UTC()
+	deadLetters := []struct {
+		id     string
+		def    string
+		tag    HookConfigurationTag
+		status HookScheduleStatus
+	}{
+		{id: "created-1", def: "on_created", tag: "tenant-1", status: HookScheduleStatusFailed},
+		{id: "created-2", def: "on_created", tag: "tenant-2", status: HookScheduleStatusQuarantined},
+		{id: "invoiced-1", def: "on_invoiced", tag: "tenant-1", status: HookScheduleStatusFailed},
+	}
+	ids := map[string]string{}
+	for i, d := range deadLetters {
+		schedule := n.MustSchedule(ctx, ID(d.id), d.def, d.tag, json.RawMessage(`{}`))
+		ids[d.id] = schedule.ID
+		schedule.Status = d.status
+		schedule.CurrentAttempt = 2
+		schedule.UpdatedAt = x.NilTime(now.Add(time.Duration(i-3) * time.Hour))
+		err = persister.WriteHookSchedule(ctx, schedule, &HookExecution{ID: d.id + "-execution", HookScheduleID: schedule.ID, ResponseStatus: 500})
+		if err != nil {
+			t.Fatalf("Failed to write schedule: %v", err)
+		}
+	}
+	pending := n.MustSchedule(ctx, ID("pending"), "on_created", "tenant-1", json.RawMessage(`{}`))
+
+	tenant1 := HookConfigurationTag("tenant-1")
+	tenant2 := HookConfigurationTag("tenant-2")
+	created := "on_created"
+	failedAfter := now.Add(-150 * time.Minute)
+
+	tests := []struct {
+		name     string
+		filter   DeadLetterFilter
+		expected []string
+	}{
+		{name: "all", expected: []string{"created-1", "created-2", "invoiced-1"}},
+		{name: "tag", filter: DeadLetterFilter{Tag: &tenant1}, expected: []string{"created-1", "invoiced-1"}},
+		{name: "requested tag", filter: DeadLetterFilter{Tag: &tenant2}, expected: []string{"created-2"}},
+		{name: "definition", filter: DeadLetterFilter{HookDefinitionID: &created}, expected: []string{"created-1", "created-2"}},
+		{name: "time", filter: DeadLetterFilter{FailedAfter: &failedAfter}, expected: []string{"created-2", "invoiced-1"}},
+		{name: "limit", filter: DeadLetterFilter{Limit: 1}, expected: []string{"created-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := n.ListDeadLetters(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("Failed to list dead letters: %v", err)
+			}
+
+			if len(res) != len(tt.expected) {
+				t.Fatalf("Expected %d dead letters, got %d", len(tt.expected), len(res))
+			}
+
+			for i := range res {
+				if res[i].ID != ids[tt.expected[i]] {
+					t.Errorf("Expected dead letter %s, got %s", ids[tt.expected[i]], res[i].ID)
+				}
+			}
+		})
+	}
+
+	_, err = n.Requeue(ctx, pending.ID)
+	if err != ErrNotDeadLetter {
+		t.Errorf("Expected ErrNotDeadLetter, got %v", err)
+	}
+
+	for _, url := range []string{"crm/v2/webhook", "ftp://crm/v2/webhook", "http://crm/%zz"} {
+		_, err = n.Requeue(ctx, ids["created-2"], WithRequeueURL(url))
+		if err == nil {
+			t.Errorf("Expected invalid requeue URL %s to fail", url)
+		}
+	}
+
+	_, err = n.RequeueDeadLetters(ctx, DeadLetterFilter{}, WithRequeueURL("crm/v2/webhook"))
+	if err == nil {
+		t.Errorf("Expected invalid requeue URL to fail")
+	}
+
+	// nothing is written with an invalid URL
+	invalid, _, err := n.FindScheduleByID(ctx, ids["created-2"])
+	if err != nil {
+		t.Fatalf("Failed to find schedule: %v", err)
+	}
+
+	if !invalid.IsDeadLetter() || invalid.URL != "http://crm/webhook" {
+		t.Errorf("Expected schedule to be left as a dead letter, got %s to %s", invalid.Status, invalid.URL)
+	}
+
+	schedule, err := n.Requeue(ctx, ids["created-2"], WithRequeueURL("http://crm/v2/webhook"))
+	if err != nil {
+		t.Fatalf("Failed to requeue: %v", err)
+	}
+
+	schedule, executions, err := n.FindScheduleByID(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("Failed to find schedule: %v", err)
+	}
+
+	if schedule.Status != HookScheduleStatusScheduled || schedule.CurrentAttempt != 0 || schedule.URL != "http://crm/v2/webhook" {
+		t.Errorf("Expected schedule to be requeued to the new URL, got %s with %d attempts to %s", schedule.Status, schedule.CurrentAttempt, schedule.URL)
+	}
+
+	if len(executions) != 1 {
+		t.Errorf("Expected execution history to be kept, got %d executions", len(executions))
+	}
+
+	requeued, err := n.RequeueDeadLetters(ctx, DeadLetterFilter{Tag: &tenant1})
+	if err != nil {
+		t.Fatalf("Failed to requeue dead letters: %v", err)
+	}
+
+	if len(requeued) != 2 {
+		t.Errorf("Expected 2 requeued dead letters, got %d", len(requeued))
+	}
+
+	remaining, err := n.ListDeadLetters(ctx, DeadLetterFilter{})
+	if err != nil {
+		t.Fatalf("Failed to list dead letters: %v", err)
+	}
+
+	if len(remaining) != 0 {
+		t.Errorf("Expected no dead letters left, got %d", len(remaining))
+	}
+}
@@ -0,0 +1,109 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// PayloadMerger combines the payload of a pending schedule with a newer payload of the same
+// coalescing key. Definitions without a merger have the pending payload replaced.
+type PayloadMerger func(ctx context.Context, pending, incoming json.RawMessage) (json.RawMessage, error)
+
+// coalesceSchedules folds schedules of debounced definitions into the pending schedules of the
+// same configuration and coalescing key, including the ones kept earlier by the batch. It
+// returns every schedule, with the coalesced ones replaced by the updated pending schedules,
+// and the schedules still to be written, which are kept by the batch.
+func (p *Nautilus) coalesceSchedules(ctx context.Context, schedules []*HookSchedule, batch *scheduleBatch) ([]*HookSchedule, []*HookSchedule, error) {
+	res := make([]*HookSchedule, len(schedules))
+	var pending []*HookSchedule
+	for i, schedule := range schedules {
+		res[i] = schedule
+
+		coalesced, err := p.coalesceSchedule(ctx, schedule, batch)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if coalesced != nil {
+			res[i] = coalesced
+			continue
+		}
+
+		pending = append(pending, schedule)
+		batch.keep(schedule)
+	}
+
+	return res, pending, nil
+}
+
+// coalesceSchedule returns the pending schedule the payload of schedule was folded into, or nil.
+func (p *Nautilus) coalesceSchedule(ctx context.Context, schedule *HookSchedule, batch *scheduleBatch) (*HookSchedule, error) {
+	definition := schedule.HookConfiguration.HookDefinition
+	if schedule.CoalescingKey == nil || definition.DebounceWindow <= 0 {
+		return nil, nil
+	}
+
+	// the pending schedule kept earlier by the batch is written along with the payload
+	unwritten, ok := batch.pending[scheduleBatchKey{schedule.HookConfigurationID, *schedule.CoalescingKey}]
+	pending := unwritten
+	if !ok {
+		var err error
+		pending, err = p.persister.FindPendingHookScheduleByCoalescingKey(ctx, schedule.HookConfigurationID, *schedule.CoalescingKey)
+		if err == ErrNotFound {
+			return nil, nil
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	payload := schedule.Payload
+	if merger, ok := p.payloadMergers[definition.ID]; ok {
+		payload, err = merger(ctx, pending.Payload, schedule.Payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if definition.PayloadScheme != nil && p.jsonSchemaValidator != nil {
+			if err := p.jsonSchemaValidator.Validate(definition.PayloadScheme, payload); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// the hash follows the payload, so later payloads are deduplicated against the pending one
+	var payloadHash *string
+	if definition.DeduplicationWindow > 0 {
+		hash, err := PayloadHash(payload)
+		if err != nil {
+			return nil, err
+		}
+		payloadHash = &hash
+	}
+
+	if unwritten != nil {
+		batch.replacePayload(unwritten, payload, payloadHash)
+		return unwritten, nil
+	}
+
+	now := time.Now().UTC()
+	replaced, err := p.persister.ReplacePendingHookSchedulePayload(ctx, pending.ID, payload, payloadHash, now)
+	if err != nil {
+		return nil, err
+	}
+
+	// attempted in the meantime, so the payload must be delivered on its own
+	if !replaced {
+		return nil, nil
+	}
+
+	pending.Payload = payload
+	pending.PayloadHash = payloadHash
+	pending.UpdatedAt = &now
+	pending.HookConfiguration = schedule.HookConfiguration
+
+	return pending, nil
+}
@@ -0,0 +1,95 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// scheduleBatch holds the schedules kept by the earlier requests of a schedule call, which are
+// not written yet, so the later requests are deduplicated and coalesced against them too.
+type scheduleBatch struct {
+	// schedules by configuration and payload hash
+	hashes map[scheduleBatchKey]*HookSchedule
+	// pending schedules by configuration and coalescing key
+	pending map[scheduleBatchKey]*HookSchedule
+}
+
+type scheduleBatchKey struct {
+	hookConfigurationID string
+	key                 string
+}
+
+func newScheduleBatch() *scheduleBatch {
+	return &scheduleBatch{
+		hashes:  make(map[scheduleBatchKey]*HookSchedule),
+		pending: make(map[scheduleBatchKey]*HookSchedule),
+	}
+}
+
+// keep records a schedule to be written by the call.
+func (b *scheduleBatch) keep(schedule *HookSchedule) {
+	if schedule.PayloadHash != nil {
+		b.hashes[scheduleBatchKey{schedule.HookConfigurationID, *schedule.PayloadHash}] = schedule
+	}
+	if schedule.CoalescingKey != nil {
+		b.pending[scheduleBatchKey{schedule.HookConfigurationID, *schedule.CoalescingKey}] = schedule
+	}
+}
+
+// replacePayload replaces the payload of a kept schedule, which no longer duplicates its previous one.
+func (b *scheduleBatch) replacePayload(schedule *HookSchedule, payload json.RawMessage, payloadHash *string) {
+	if schedule.PayloadHash != nil {
+		delete(b.hashes, scheduleBatchKey{schedule.HookConfigurationID, *schedule.PayloadHash})
+	}
+
+	schedule.Payload = payload
+	schedule.PayloadHash = payloadHash
+	b.keep(schedule)
+}
+
+// deduplicateSchedules drops the schedules whose payload hash matches a schedule of the same
+// configuration created within the deduplication window of its definition, or kept earlier by
+// the batch. The schedules they duplicate are returned along with the kept schedules.
+func (p *Nautilus) deduplicateSchedules(ctx context.Context, schedules []*HookSchedule, batch *scheduleBatch) ([]*HookSchedule, []*HookSchedule, error) {
+	var res, originals []*HookSchedule
+	for _, schedule := range schedules {
+		window := schedule.HookConfiguration.HookDefinition.DeduplicationWindow
+		if schedule.PayloadHash == nil || window <= 0 {
+			res = append(res, schedule)
+			continue
+		}
+
+		original, ok := batch.hashes[scheduleBatchKey{schedule.HookConfigurationID, *schedule.PayloadHash}]
+		if ok {
+			originals = append(originals, original)
+			continue
+		}
+
+		original, err := p.persister.FindHookScheduleByPayloadHash(ctx,
+			schedule.HookConfigurationID,
+			*schedule.PayloadHash,
+			time.Now().UTC().Add(-window))
+		if err == ErrNotFound {
+			res = append(res, schedule)
+			continue
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		originals = append(originals, original)
+	}
+
+	return res, originals, nil
+}
+
+// duplicatePayloadError fails a schedule call whose payload was dropped for every configuration.
+func duplicatePayloadError(schedules []*HookSchedule, originals []*HookSchedule) error {
+	if len(schedules) > 0 || len(originals) == 0 {
+		return nil
+	}
+
+	return &DuplicatePayloadError{Originals: originals}
+}
@@ -11,13 +11,20 @@ import (
 
 type (
 	yamlDefinition struct {
-		ID                string              `yaml:"id"`
-		Name              string              `yaml:"name"`
-		Description       string              `yaml:"description"`
-		PayloadScheme     string              `yaml:"payload_scheme"`
-		HttpRequestMethod HttpRequestMethod   `yaml:"http_request_method"`
-		TotalAttempts     int                 `yaml:"total_attempts"`
-		Configurations    []yamlConfiguration `yaml:"configurations"`
+		ID                    string                `yaml:"id"`
+		Name                  string                `yaml:"name"`
+		Description           string                `yaml:"description"`
+		PayloadScheme         string                `yaml:"payload_scheme"`
+		HttpRequestMethod     HttpRequestMethod     `yaml:"http_request_method"`
+		TotalAttempts         int                   `yaml:"total_attempts"`
+		OrderingFailurePolicy OrderingFailurePolicy `yaml:"ordering_failure_policy"`
+		Priority              int                   `yaml:"priority"`
+		BatchMaxSize          int                   `yaml:"batch_max_size"`
+		BatchMaxWait          time.Duration         `yaml:"batch_max_wait"`
+		DebounceWindow        time.Duration         `yaml:"debounce_window"`
+		DebounceMaxWait       time.Duration         `yaml:"debounce_max_wait"`
+		DeduplicationWindow   time.Duration         `yaml:"deduplication_window"`
+		Configurations        []yamlConfiguration   `yaml:"configurations"`
 	}
 	yamlConfiguration struct {
 		ID                  string               `yaml:"id"`
@@ -25,9 +32,12 @@ type (
 		URL                 string               `yaml:"url"`
 		ClientSecret        *string              `yaml:"client_secret"`
 		ClientRSAPrivateKey *string              `yaml:"client_rsa_private_key"`
+		Disabled            bool                 `yaml:"disabled"`
+		Filter              *string              `yaml:"filter"`
 	}
 	nautilusYamlConfig struct {
-		Definitions []*yamlDefinition `yaml:"definitions"`
+		Definitions   []*yamlDefinition   `yaml:"definitions"`
+		Subscriptions []*HookSubscription `yaml:"subscriptions"`
 	}
 )
 
@@ -42,12 +52,19 @@ func (p *Nautilus) LoadFromYamlBytes(ctx context.Context, data []byte) error {
 	var configs []*HookConfiguration
 	for _, def := range config.Definitions {
 		definition := &HookDefinition{
-			ID:                def.ID,
-			Name:              def.Name,
-			Description:       def.Description,
-			PayloadScheme:     json.RawMessage(def.PayloadScheme),
-			HttpRequestMethod: def.HttpRequestMethod,
-			TotalAttempts:     def.TotalAttempts,
+			ID:                    def.ID,
+			Name:                  def.Name,
+			Description:           def.Description,
+			PayloadScheme:         json.RawMessage(def.PayloadScheme),
+			HttpRequestMethod:     def.HttpRequestMethod,
+			TotalAttempts:         def.TotalAttempts,
+			OrderingFailurePolicy: def.OrderingFailurePolicy,
+			Priority:              def.Priority,
+			BatchMaxSize:          def.BatchMaxSize,
+			BatchMaxWait:          def.BatchMaxWait,
+			DebounceWindow:        def.DebounceWindow,
+			DebounceMaxWait:       def.DebounceMaxWait,
+			DeduplicationWindow:   def.DeduplicationWindow,
 		}
 		if def.Configurations != nil {
 			for _, conf := range def.Configurations {
@@ -59,6 +76,8 @@ func (p *Nautilus) LoadFromYamlBytes(ctx context.Context, data []byte) error {
 					URL:                 conf.URL,
 					ClientSecret:        conf.ClientSecret,
 					ClientRSAPrivateKey: conf.ClientRSAPrivateKey,
+					Disabled:            conf.Disabled,
+					Filter:              conf.Filter,
 					HookDefinition:      definition,
 				}
 				configs = append(configs, configuration)
@@ -77,6 +96,11 @@ func (p *Nautilus) LoadFromYamlBytes(ctx context.Context, data []byte) error {
 		return err
 	}
 
+	err = p.RegisterSubscriptions(ctx, config.Subscriptions...)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -2,6 +2,7 @@ package nautilus
 
 import (
 	"net/http"
+	"time"
 )
 
 func WithPersister(persister NautilusPersister) func(*Nautilus) {
@@ -18,7 +19,39 @@ func WithHttpClient(httpClient *http.Client) func(*Nautilus) {
 
 func WithWorkersCount(workersCount int) func(*Nautilus) {
 	return func(n *Nautilus) {
-		n.workersCount = workersCount
+		n.minWorkersCount = workersCount
+		n.maxWorkersCount = workersCount
+	}
+}
+
+// WithWorkerPoolSize lets the worker pool scale between min and max workers, from the depth
+// of the queue and the latency of the deliveries. Bounds ResizeWorkers rejects with
+// ErrInvalidWorkersCount are clamped by New to at least 1 worker, and max to at least min.
+func WithWorkerPoolSize(min, max int) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.minWorkersCount = min
+		n.maxWorkersCount = max
+	}
+}
+
+// WithWorkerScaleInterval sets how often the worker pool is scaled.
+func WithWorkerScaleInterval(workerScaleInterval time.Duration) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.workerScaleInterval = workerScaleInterval
+	}
+}
+
+// WithWorkerPool adds a worker pool isolating the schedules routed to it, replacing the pool
+// of the same name. Pools are matched in the order they were added.
+func WithWorkerPool(pool WorkerPool) func(*Nautilus) {
+	return func(n *Nautilus) {
+		for i := range n.workerPools {
+			if n.workerPools[i].Name == pool.Name {
+				n.workerPools[i] = pool
+				return
+			}
+		}
+		n.workerPools = append(n.workerPools, pool)
 	}
 }
 
@@ -46,20 +79,82 @@ func WithJsonSchemaValidator(validator JSchemaValidator) func(*Nautilus) {
 	}
 }
 
+func WithRecurringInterval(recurringInterval time.Duration) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.recurringInterval = recurringInterval
+	}
+}
+
+func WithPayloadGenerator(name string, generator PayloadGenerator) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.payloadGenerators[name] = generator
+	}
+}
+
+// WithInFlightLease sets for how long a dispatched schedule is not dispatched again while
+// it is queued or running, recovering schedules of stuck workers once it is over.
+func WithInFlightLease(inFlightLease time.Duration) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.inFlightLease = inFlightLease
+	}
+}
+
+// WithRetryInterval sets how long a failed attempt waits before being retried, and how long a
+// schedule blocked by its ordering key waits before being checked again.
+func WithRetryInterval(retryInterval time.Duration) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.retryInterval = retryInterval
+	}
+}
+
+// WithQuarantineThreshold sets after how many panicked deliveries a schedule is quarantined.
+// Zero disables the quarantine, leaving panicked deliveries to fail like failed attempts.
+func WithQuarantineThreshold(quarantineThreshold int) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.quarantineThreshold = quarantineThreshold
+	}
+}
+
+// WithPayloadMerger merges, instead of replacing, the payloads coalesced into pending
+// schedules of the hook definition.
+func WithPayloadMerger(hookDefinitionID string, merger PayloadMerger) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.payloadMergers[hookDefinitionID] = merger
+	}
+}
+
+func WithTagFallback(tagFallback TagFallback) func(*Nautilus) {
+	return func(n *Nautilus) {
+		n.tagFallback = tagFallback
+	}
+}
+
 func New(options ...func(*Nautilus)) *Nautilus {
 	n := &Nautilus{
 		jsonSchemaValidator: NewStandardJsonSchemaValidator(),
 		persister:           NewInMemoryPersister(),
 		httpClient:          http.DefaultClient,
-		workersCount:        5, // default values
+		minWorkersCount:     5, // default values
+		maxWorkersCount:     5,
+		workerScaleInterval: 5 * time.Second,
 		scheduleBufferSize:  100,
 		errCh:               nil,
+		recurringInterval:   10 * time.Second,
+		inFlightLease:       5 * time.Minute,
+		retryInterval:       40 * time.Second,
+		quarantineThreshold: 3,
+		payloadGenerators:   make(map[string]PayloadGenerator),
+		payloadMergers:      make(map[string]PayloadMerger),
 	}
 
 	for i := range options {
 		options[i](n)
 	}
 
+	// the pool always runs a worker, and cannot scale below its min
+	n.minWorkersCount = max(n.minWorkersCount, 1)
+	n.maxWorkersCount = max(n.maxWorkersCount, n.minWorkersCount)
+
 	// default scheduler
 	if n.scheduler == nil {
 		n.scheduler = NewPollScheduler(n.persister)
@@ -0,0 +1,70 @@
+package nautilus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/devmalloni/nautilus/x"
+)
+
+// PanicError is reported when the delivery of a schedule panicked.
+type PanicError struct {
+	HookScheduleID string
+	Value          any
+	Stack          []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("delivery of schedule %s panicked: %v", e.HookScheduleID, e.Value)
+}
+
+// executeRecovered executes the dispatched schedule, recovering a panic of its delivery, e.g on
+// a schedule loaded without its configuration, so a poison schedule does not kill the worker.
+func (p *Nautilus) executeRecovered(ctx context.Context, schedule *HookSchedule) (err error) {
+	id := schedule.ID
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+
+		panicErr := &PanicError{HookScheduleID: id, Value: r, Stack: debug.Stack()}
+		err = panicErr
+		if recordErr := p.recordPanic(ctx, panicErr); recordErr != nil {
+			err = errors.Join(panicErr, recordErr)
+		}
+	}()
+
+	return p.executeDispatched(ctx, id, schedule.ClaimedBy)
+}
+
+// recordPanic records the panic as a failed execution of the schedule, with the stack trace
+// as its response, and quarantines the schedule once it panicked too many times.
+func (p *Nautilus) recordPanic(ctx context.Context, panicErr *PanicError) error {
+	schedule, _, err := p.persister.FindHookSchedulesByID(ctx, panicErr.HookScheduleID)
+	if err != nil {
+		return err
+	}
+
+	schedule.registerPanic(p.quarantineThreshold)
+	p.scheduleRetry(schedule)
+
+	execution := &HookExecution{
+		ID:              x.NewUUIDStr(),
+		HookScheduleID:  schedule.ID,
+		ResponsePayload: x.NullString(fmt.Sprintf("panic: %v\n\n%s", panicErr.Value, panicErr.Stack)),
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	err = p.persister.WriteHookSchedule(ctx, schedule, execution)
+	if err != nil {
+		return err
+	}
+
+	p.notifyScheduler(schedule)
+
+	return nil
+}
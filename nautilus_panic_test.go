@@ -0,0 +1,70 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type panickingTransport struct{}
+
+func (panickingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	panic("poison schedule")
+}
+
+func TestNautilus_ExecuteRecovered(t *testing.T) {
+	ctx := context.Background()
+
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithHttpClient(&http.Client{Transport: panickingTransport{}}),
+		WithQuarantineThreshold(2))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 5})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	schedule := n.MustSchedule(ctx, ID("poison"), "on_created", Global, json.RawMessage(`{}`))
+
+	expected := []HookScheduleStatus{HookScheduleStatusScheduled, HookScheduleStatusQuarantined}
+	for i, status := range expected {
+		err = n.executeRecovered(ctx, schedule)
+
+		var panicErr *PanicError
+		if !errors.As(err, &panicErr) {
+			t.Fatalf("Expected PanicError, got %v", err)
+		}
+
+		schedule, executions, err := n.FindScheduleByID(ctx, schedule.ID)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.Status != status {
+			t.Errorf("Expected status %s, got %s", status, schedule.Status)
+		}
+
+		if schedule.PanicCount != i+1 || schedule.CurrentAttempt != i+1 {
+			t.Errorf("Expected %d panics and attempts, got %d and %d", i+1, schedule.PanicCount, schedule.CurrentAttempt)
+		}
+
+		if len(executions) != i+1 {
+			t.Fatalf("Expected %d executions, got %d", i+1, len(executions))
+		}
+
+		payload := executions[len(executions)-1].ResponsePayload
+		if payload == nil || !strings.Contains(*payload, "poison schedule") || !strings.Contains(*payload, "goroutine") {
+			t.Errorf("Expected execution to record the panic with its stack trace, got %v", payload)
+		}
+	}
+}
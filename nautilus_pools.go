@@ -0,0 +1,95 @@
+package nautilus
+
+import (
+	"slices"
+)
+
+// WorkerPool is a pool of workers isolated from the others, so the slow endpoints of a tenant
+// only hold back the schedules routed to the same pool. Schedules are routed to the first pool
+// matching the tag they were requested for, their hook definition or their priority, and to the default pool, sized
+// by WithWorkersCount or WithWorkerPoolSize, otherwise.
+//
+// Inside a pool, as in the default one, the schedules are served by priority unless Fair is set.
+// Schedules dispatched to a full pool do not wait for it, so the other pools
+// keep being dispatched to; claimed ones are backed off by the retry interval.
+type WorkerPool struct {
+	Name string
+	// WorkersCount defaults to 1.
+	WorkersCount int
+	// BufferSize is the number of schedules queued in the pool, defaulting to 1.
+	BufferSize        int
+	Tags              []HookConfigurationTag
+	HookDefinitionIDs []string
+	// MinPriority routes the schedules of at least this priority, when set.
+	MinPriority *int
+	// Fair serves the tags of the pool in turns, so a tag with many queued schedules does not
+	// starve the others, at the cost of the priority order across tags.
+	Fair bool
+}
+
+func (p *WorkerPool) matches(schedule *HookSchedule) bool {
+	if p.MinPriority != nil && schedule.Priority >= *p.MinPriority {
+		return true
+	}
+
+	if slices.Contains(p.Tags, schedule.Tag) {
+		return true
+	}
+
+	configuration := schedule.HookConfiguration
+	return configuration != nil && slices.Contains(p.HookDefinitionIDs, configuration.HookDefinitionID)
+}
+
+// runPool is a worker pool of a Run, with its own queue.
+type runPool struct {
+	queue   *scheduleQueue
+	workers *workerPool
+	route   *WorkerPool
+}
+
+// tenantOf identifies the tenant of a schedule for the fair queues of the pools, by the tag it
+// was requested for, so the tenants resolved to a global configuration do not share a turn.
+func tenantOf(schedule *HookSchedule) string {
+	if schedule.Tag == "" {
+		return schedule.HookConfigurationID
+	}
+
+	return string(schedule.Tag)
+}
+
+// newRunPools returns the default pool, followed by the pools of WithWorkerPool in routing order.
+func (p *Nautilus) newRunPools(execute func(schedule *HookSchedule)) []*runPool {
+	queue := newScheduleQueue(p.scheduleBufferSize)
+	pools := []*runPool{{
+		queue:   queue,
+		workers: newWorkerPool(p.minWorkersCount, p.maxWorkersCount, queue, execute),
+	}}
+
+	for i := range p.workerPools {
+		route := &p.workerPools[i]
+		queue := newScheduleQueue(route.BufferSize)
+		if route.Fair {
+			queue = newFairScheduleQueue(route.BufferSize, tenantOf)
+		}
+		workersCount := max(route.WorkersCount, 1)
+		pools = append(pools, &runPool{
+			queue:   queue,
+			workers: newWorkerPool(workersCount, workersCount, queue, execute),
+			route:   route,
+		})
+	}
+
+	return pools
+}
+
+// routeSchedule returns the pool of the schedule. Schedules are read for dispatching along
+// with their configuration, so they are routed on its tag or hook definition.
+func routeSchedule(pools []*runPool, schedule *HookSchedule) *runPool {
+	for _, pool := range pools[1:] {
+		if pool.route.matches(schedule) {
+			return pool
+		}
+	}
+
+	return pools[0]
+}
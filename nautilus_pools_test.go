@@ -0,0 +1,237 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNautilus_RouteSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	persister := NewInMemoryPersister()
+	urgent := 10
+	n := New(
+		WithPersister(persister),
+		WithWorkerPool(WorkerPool{Name: "urgent", MinPriority: &urgent}),
+		WithWorkerPool(WorkerPool{Name: "tenant", Tags: []HookConfigurationTag{"tenant-1"}}),
+		WithWorkerPool(WorkerPool{Name: "invoices", HookDefinitionIDs: []string{"on_invoiced"}}))
+
+	err := n.RegisterDefinitions(ctx,
+		&HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1},
+		&HookDefinition{ID: "on_invoiced", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "tenant-1-created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "tenant-1"},
+		&HookConfiguration{ID: "global-created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: Global},
+		&HookConfiguration{ID: "global-invoiced", HookDefinitionID: "on_invoiced", URL: "http://crm/webhook", Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	pools := n.newRunPools(func(*HookSchedule) {})
+
+	// schedules are read for dispatching along with their configuration
+	err = persister.WriteHookSchedules(ctx, []*HookSchedule{
+		{ID: "urgent", HookConfigurationID: "tenant-1-created", Tag: "tenant-1", Status: HookScheduleStatusScheduled, Priority: 10},
+		{ID: "tenant", HookConfigurationID: "tenant-1-created", Tag: "tenant-1", Status: HookScheduleStatusScheduled},
+		// resolved to a global configuration through the tag fallback
+		{ID: "fallback", HookConfigurationID: "global-created", Tag: "tenant-1", Status: HookScheduleStatusScheduled},
+		{ID: "invoices", HookConfigurationID: "global-invoiced", Tag: Global, Status: HookScheduleStatusScheduled},
+		{ID: "default", HookConfigurationID: "global-created", Tag: Global, Status: HookScheduleStatusScheduled},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write schedules: %v", err)
+	}
+
+	schedules, err := persister.FindDueHookSchedules(ctx, time.Now().UTC(), nil, 10)
+	if err != nil {
+		t.Fatalf("Failed to find schedules: %v", err)
+	}
+
+	if len(schedules) != 5 {
+		t.Fatalf("Expected 5 due schedules, got %d", len(schedules))
+	}
+
+	for _, schedule := range schedules {
+		t.Run(schedule.ID, func(t *testing.T) {
+			pool := routeSchedule(pools, schedule)
+
+			name := ""
+			if pool.route != nil {
+				name = pool.route.Name
+			}
+			expected := schedule.ID
+			switch expected {
+			case "default":
+				expected = ""
+			case "fallback":
+				expected = "tenant"
+			}
+			if name != expected {
+				t.Errorf("Expected pool %q, got %q", expected, name)
+			}
+		})
+	}
+}
+
+func TestNautilus_NewRunPools_Fair(t *testing.T) {
+	n := New(
+		WithPersister(NewInMemoryPersister()),
+		WithScheduleBufferSize(10),
+		WithWorkerPool(WorkerPool{Name: "fair", BufferSize: 10, Fair: true}))
+
+	pools := n.newRunPools(func(*HookSchedule) {})
+
+	tests := []struct {
+		name     string
+		pool     *runPool
+		expected []string
+	}{
+		// the default pool keeps the priority order across tags
+		{name: "default", pool: pools[0], expected: []string{"noisy-2", "noisy-3", "noisy-1", "quiet-1"}},
+		{name: "fair", pool: pools[1], expected: []string{"noisy-2", "quiet-1", "noisy-3", "noisy-1"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.pool.queue.Push(&HookSchedule{ID: "noisy-1", Tag: "noisy"})
+			tt.pool.queue.Push(&HookSchedule{ID: "noisy-2", Tag: "noisy", Priority: 10})
+			tt.pool.queue.Push(&HookSchedule{ID: "noisy-3", Tag: "noisy", Priority: 10})
+			tt.pool.queue.Push(&HookSchedule{ID: "quiet-1", Tag: "quiet"})
+
+			for _, id := range tt.expected {
+				s, ok := tt.pool.queue.Pop()
+				if !ok {
+					t.Fatalf("Expected schedule %s, got closed queue", id)
+				}
+				if s.ID != id {
+					t.Errorf("Expected schedule %s, got %s", id, s.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestNautilus_Run_WorkerPools(t *testing.T) {
+	quietCalled := make(chan struct{}, 1)
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		io.ReadAll(req.Body)
+		if req.URL.Path == "/quiet" {
+			quietCalled <- struct{}{}
+			res.WriteHeader(200)
+			return
+		}
+
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithWorkersCount(1),
+		WithWorkerPool(WorkerPool{Name: "noisy", WorkersCount: 1, BufferSize: 10, Tags: []HookConfigurationTag{"noisy"}}),
+		WithScheduler(NewPollScheduler(persister, WithRunnerInterval(10*time.Millisecond))))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "noisy", HookDefinitionID: "on_created", URL: testServer.URL + "/noisy", Tag: "noisy"},
+		&HookConfiguration{ID: "quiet", HookDefinitionID: "on_created", URL: testServer.URL + "/quiet", Tag: "quiet"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		n.MustSchedule(ctx, nil, "on_created", "noisy", json.RawMessage(`{}`))
+	}
+	n.MustSchedule(ctx, nil, "on_created", "quiet", json.RawMessage(`{}`))
+
+	go n.Run(ctx)
+
+	// the noisy tenant holds the only worker of its pool, not the default one
+	select {
+	case <-quietCalled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected quiet tenant to be delivered while the noisy one is stuck")
+	}
+}
+
+func TestNautilus_Run_FullDefaultPool(t *testing.T) {
+	isolatedCalled := make(chan struct{}, 1)
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		io.ReadAll(req.Body)
+		if req.URL.Path == "/isolated" {
+			isolatedCalled <- struct{}{}
+			res.WriteHeader(200)
+			return
+		}
+
+		select {
+		case <-release:
+		case <-req.Context().Done():
+		}
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithWorkersCount(1),
+		WithScheduleBufferSize(1),
+		WithWorkerPool(WorkerPool{Name: "isolated", WorkersCount: 1, Tags: []HookConfigurationTag{"isolated"}}),
+		WithScheduler(NewPollScheduler(persister, WithRunnerInterval(10*time.Millisecond))))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "stuck", HookDefinitionID: "on_created", URL: testServer.URL + "/stuck", Tag: "stuck"},
+		&HookConfiguration{ID: "isolated", HookDefinitionID: "on_created", URL: testServer.URL + "/isolated", Tag: "isolated"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	// more than the default pool holds, dispatched ahead of the isolated schedule
+	for i := 0; i < 5; i++ {
+		n.MustSchedule(ctx, nil, "on_created", "stuck", json.RawMessage(`{}`), WithPriority(10))
+	}
+	n.MustSchedule(ctx, nil, "on_created", "isolated", json.RawMessage(`{}`))
+
+	go n.Run(ctx)
+
+	// the full default pool does not hold back the dispatch to the isolated pool
+	select {
+	case <-isolatedCalled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected isolated pool to be delivered while the default one is full")
+	}
+}
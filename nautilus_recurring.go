@@ -0,0 +1,142 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// PayloadGenerator builds the payload of a recurring schedule firing.
+type PayloadGenerator func(ctx context.Context, r *HookRecurringSchedule, firedAt time.Time) (json.RawMessage, error)
+
+func (p *Nautilus) RegisterRecurringSchedules(ctx context.Context, recurringSchedules ...*HookRecurringSchedule) error {
+	for i := range recurringSchedules {
+		configuration, err := p.persister.FindHookConfigurationByID(ctx, recurringSchedules[i].HookConfigurationID)
+		if err != nil {
+			return err
+		}
+		recurringSchedules[i].HookConfiguration = configuration
+
+		if err := recurringSchedules[i].IsValid(); err != nil {
+			return err
+		}
+
+		if generator := recurringSchedules[i].PayloadGenerator; generator != nil {
+			if _, ok := p.payloadGenerators[*generator]; !ok {
+				return fmt.Errorf("payload generator %s is not registered", *generator)
+			}
+		}
+
+		now := time.Now().UTC()
+		if recurringSchedules[i].CreatedAt.IsZero() {
+			recurringSchedules[i].CreatedAt = now
+		}
+
+		if recurringSchedules[i].NextRunAt.IsZero() {
+			next, err := recurringSchedules[i].Next(now)
+			if err != nil {
+				return err
+			}
+			recurringSchedules[i].NextRunAt = next
+		}
+
+		err = p.persister.WriteHookRecurringSchedule(ctx, recurringSchedules[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p *Nautilus) ListRecurringSchedules(ctx context.Context) ([]*HookRecurringSchedule, error) {
+	return p.persister.FindHookRecurringSchedules(ctx)
+}
+
+func (p *Nautilus) runRecurringSchedules(ctx context.Context, errCh chan<- error) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.recurringInterval):
+			now := time.Now().UTC()
+			recurringSchedules, err := p.persister.FindDueHookRecurringSchedules(ctx, now)
+			if err != nil {
+				if errCh != nil {
+					errCh <- err
+				}
+				continue
+			}
+
+			for i := range recurringSchedules {
+				err := p.fireRecurringSchedule(ctx, recurringSchedules[i], now)
+				if err != nil && errCh != nil {
+					errCh <- err
+				}
+			}
+		}
+	}
+}
+
+// fireRecurringSchedule schedules the pending firing of r and moves it to its next run.
+// Firings missed while the engine was down are collapsed into a single one, and firings of
+// a disabled configuration are skipped.
+func (p *Nautilus) fireRecurringSchedule(ctx context.Context, r *HookRecurringSchedule, now time.Time) error {
+	firedAt := r.NextRunAt
+
+	if !r.HookConfiguration.Disabled {
+		err := p.scheduleRecurringFiring(ctx, r, firedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	next, err := r.Next(now)
+	if err != nil {
+		return err
+	}
+	r.LastRunAt = &firedAt
+	r.NextRunAt = next
+
+	return p.persister.WriteHookRecurringSchedule(ctx, r)
+}
+
+func (p *Nautilus) scheduleRecurringFiring(ctx context.Context, r *HookRecurringSchedule, firedAt time.Time) error {
+	payload := r.Payload
+	if r.PayloadGenerator != nil {
+		generator, ok := p.payloadGenerators[*r.PayloadGenerator]
+		if !ok {
+			return fmt.Errorf("payload generator %s is not registered", *r.PayloadGenerator)
+		}
+
+		var err error
+		payload, err = generator(ctx, r, firedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	schedule, err := r.Fire(firedAt, payload, p.jsonSchemaValidator)
+	if err != nil && err != ErrPayloadFiltered {
+		return err
+	}
+
+	if schedule == nil {
+		return nil
+	}
+
+	// the firing is only inserted, as another instance may have fired it already, and even
+	// claimed or delivered it since
+	_, err = p.persister.WriteIdempotentHookSchedules(ctx, []*HookSchedule{schedule})
+	if err == ErrScheduleAlreadyExists {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	p.notifyScheduler(schedule)
+
+	return nil
+}
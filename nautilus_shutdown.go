@@ -0,0 +1,139 @@
+package nautilus
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	ErrNotRunning = errors.New("nautilus is not running")
+)
+
+// runState tracks a Run, so Shutdown can stop it and wait for its workers.
+type runState struct {
+	cancelRun  context.CancelFunc
+	cancelExec context.CancelFunc
+	queues     []*scheduleQueue
+	done       chan struct{}
+	pool       *workerPool
+	// dispatched is closed once the dispatcher stopped and closed the queues
+	dispatched chan struct{}
+
+	l        sync.Mutex
+	inFlight map[string]*HookSchedule
+	dropped  []*HookSchedule
+}
+
+func newRunState(cancelRun, cancelExec context.CancelFunc) *runState {
+	return &runState{
+		cancelRun:  cancelRun,
+		cancelExec: cancelExec,
+		done:       make(chan struct{}),
+		dispatched: make(chan struct{}),
+		inFlight:   make(map[string]*HookSchedule),
+	}
+}
+
+// drop records a schedule the dispatcher did not queue because the run was stopping.
+func (s *runState) drop(schedule *HookSchedule) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.dropped = append(s.dropped, schedule)
+}
+
+func (s *runState) droppedSchedules() []*HookSchedule {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	return s.dropped
+}
+
+func (s *runState) start(schedule *HookSchedule) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	s.inFlight[schedule.ID] = schedule
+}
+
+func (s *runState) finish(schedule *HookSchedule) {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	delete(s.inFlight, schedule.ID)
+}
+
+func (s *runState) executing() []*HookSchedule {
+	s.l.Lock()
+	defer s.l.Unlock()
+
+	res := make([]*HookSchedule, 0, len(s.inFlight))
+	for _, schedule := range s.inFlight {
+		res = append(res, schedule)
+	}
+
+	return res
+}
+
+// Shutdown stops the scheduler of the running Run and waits for the workers to finish and
+// persist their in-flight executions. Once the scheduler stopped dispatching, queued schedules
+// that were not started are abandoned right away, and if ctx is done before the workers
+// finish, the in-flight executions are cancelled and abandoned too. Abandoned schedules are
+// returned, along with ctx.Err() when the deadline was hit. They keep their status, so they
+// are dispatched again by the next Run.
+func (p *Nautilus) Shutdown(ctx context.Context) ([]*HookSchedule, error) {
+	p.runLock.Lock()
+	state := p.running
+	p.runLock.Unlock()
+
+	if state == nil {
+		return nil, ErrNotRunning
+	}
+
+	// the scheduler is stopped first and the dispatcher waited for, so no schedule is queued
+	// once the queues are drained
+	state.cancelRun()
+	select {
+	case <-state.dispatched:
+	case <-ctx.Done():
+	}
+
+	var drained []*HookSchedule
+	for _, queue := range state.queues {
+		drained = append(drained, queue.Drain()...)
+	}
+	releaseErr := p.releaseAbandoned(context.WithoutCancel(ctx), drained)
+
+	// schedules dispatched meanwhile are dropped by the drained queues, and released by the dispatcher
+	select {
+	case <-state.dispatched:
+	case <-ctx.Done():
+	}
+	abandoned := append(drained, state.droppedSchedules()...)
+
+	select {
+	case <-state.done:
+		return abandoned, releaseErr
+	case <-ctx.Done():
+		executing := state.executing()
+		state.cancelExec()
+		abandoned = append(abandoned, executing...)
+		releaseErr = errors.Join(releaseErr, p.releaseAbandoned(context.WithoutCancel(ctx), executing))
+		if releaseErr != nil {
+			return abandoned, errors.Join(ctx.Err(), releaseErr)
+		}
+		return abandoned, ctx.Err()
+	}
+}
+
+// releaseAbandoned releases the claims of the abandoned schedules, so they are claimed again by
+// the next poll of any instance instead of once their lease is over.
+func (p *Nautilus) releaseAbandoned(ctx context.Context, schedules []*HookSchedule) error {
+	var errs []error
+	for _, schedule := range schedules {
+		errs = append(errs, p.releaseClaim(ctx, schedule))
+	}
+
+	return errors.Join(errs...)
+}
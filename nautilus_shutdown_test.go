@@ -0,0 +1,132 @@
+package nautilus
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestNautilus_Shutdown(t *testing.T) {
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		// the request context is only cancelled once the body is read
+		io.ReadAll(req.Body)
+		started <- struct{}{}
+		select {
+		case <-release:
+		case <-req.Context().Done():
+			return
+		}
+
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+
+	ctx := context.Background()
+
+	setup := func(t *testing.T) (*Nautilus, chan struct{}) {
+		persister := NewInMemoryPersister()
+		n := New(
+			WithPersister(persister),
+			WithWorkersCount(1),
+			WithScheduler(NewPollScheduler(persister, WithRunnerInterval(10*time.Millisecond))))
+
+		err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1})
+		if err != nil {
+			t.Fatalf("Failed to register definitions: %v", err)
+		}
+
+		err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: testServer.URL, Tag: Global})
+		if err != nil {
+			t.Fatalf("Failed to register configurations: %v", err)
+		}
+
+		stopped := make(chan struct{})
+		go func() {
+			n.Run(ctx)
+			close(stopped)
+		}()
+
+		n.MustSchedule(ctx, ID("in_flight"), "on_created", Global, json.RawMessage(`{}`))
+
+		select {
+		case <-started:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Webhook was not called")
+		}
+
+		return n, stopped
+	}
+
+	t.Run("drains in-flight executions", func(t *testing.T) {
+		n, stopped := setup(t)
+
+		go func() {
+			<-time.After(100 * time.Millisecond)
+			release <- struct{}{}
+		}()
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+
+		abandoned, err := n.Shutdown(shutdownCtx)
+		if err != nil {
+			t.Fatalf("Failed to shutdown: %v", err)
+		}
+
+		if len(abandoned) != 0 {
+			t.Errorf("Expected no abandoned schedules, got %d", len(abandoned))
+		}
+
+		<-stopped
+
+		schedule, executions, err := n.FindScheduleByID(ctx, "in_flight")
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.Status != HookScheduleStatusExecuted || len(executions) != 1 {
+			t.Errorf("Expected in-flight execution to be persisted, got status %s with %d executions", schedule.Status, len(executions))
+		}
+
+		if _, err := n.Shutdown(shutdownCtx); err != ErrNotRunning {
+			t.Errorf("Expected not running error, got %v", err)
+		}
+	})
+
+	t.Run("abandons executions past the deadline", func(t *testing.T) {
+		n, stopped := setup(t)
+
+		shutdownCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+		defer cancel()
+
+		abandoned, err := n.Shutdown(shutdownCtx)
+		if err != context.DeadlineExceeded {
+			t.Fatalf("Expected deadline exceeded, got %v", err)
+		}
+
+		if len(abandoned) != 1 || abandoned[0].ID != "in_flight" {
+			t.Errorf("Expected in-flight schedule to be abandoned, got %v", abandoned)
+		}
+
+		select {
+		case <-stopped:
+		case <-time.After(2 * time.Second):
+			t.Fatal("Run did not return after the in-flight execution was cancelled")
+		}
+
+		schedule, _, err := n.FindScheduleByID(ctx, "in_flight")
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.ClaimedBy != nil {
+			t.Errorf("Expected the claim of the abandoned schedule to be released")
+		}
+	})
+}
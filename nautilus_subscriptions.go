@@ -0,0 +1,99 @@
+package nautilus
+
+import (
+	"context"
+	"time"
+
+	"github.com/devmalloni/nautilus/x"
+)
+
+// RegisterSubscriptions writes the subscriptions and reconciles their configurations with the
+// registered definitions: configurations are written for the definitions they match, and the
+// ones of definitions they no longer match are disabled.
+func (p *Nautilus) RegisterSubscriptions(ctx context.Context, subscriptions ...*HookSubscription) error {
+	definitions, err := p.persister.FindHookDefinitions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := range subscriptions {
+		if err := subscriptions[i].IsValid(); err != nil {
+			return err
+		}
+
+		if subscriptions[i].CreatedAt.IsZero() {
+			subscriptions[i].CreatedAt = time.Now().UTC()
+		}
+
+		err := p.persister.WriteHookSubscription(ctx, subscriptions[i])
+		if err != nil {
+			return err
+		}
+
+		for _, definition := range definitions {
+			err = p.reconcileSubscription(ctx, subscriptions[i], definition)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Nautilus) ListSubscriptionsOfTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSubscription, error) {
+	return p.persister.FindHookSubscriptionsByTag(ctx, tag)
+}
+
+// reconcileSubscriptions reconciles the configurations of every subscription with the
+// definition, e.g once it is registered.
+func (p *Nautilus) reconcileSubscriptions(ctx context.Context, definition *HookDefinition) error {
+	subscriptions, err := p.persister.FindHookSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, subscription := range subscriptions {
+		err = p.reconcileSubscription(ctx, subscription, definition)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileSubscription makes sure the subscription has an up to date configuration for the
+// definition if it matches it, and disables its configuration otherwise.
+func (p *Nautilus) reconcileSubscription(ctx context.Context, subscription *HookSubscription, definition *HookDefinition) error {
+	configuration := subscription.Configuration(definition)
+	existing, err := p.persister.FindHookConfigurationByID(ctx, configuration.ID)
+	if err != nil && err != ErrNotFound {
+		return err
+	}
+
+	if !subscription.Matches(definition.ID) {
+		if existing == nil || existing.Disabled {
+			return nil
+		}
+
+		disabled := *existing
+		disabled.Disabled = true
+		return p.persister.WriteHookConfiguration(ctx, &disabled)
+	}
+
+	if existing != nil {
+		// configurations of a subscription can still be disabled one by one
+		configuration.Disabled = existing.Disabled
+
+		if existing.Tag == configuration.Tag &&
+			existing.URL == configuration.URL &&
+			x.EqualNullString(existing.ClientSecret, configuration.ClientSecret) &&
+			x.EqualNullString(existing.ClientRSAPrivateKey, configuration.ClientRSAPrivateKey) &&
+			x.EqualNullString(existing.Filter, configuration.Filter) {
+			return nil
+		}
+	}
+
+	return p.persister.WriteHookConfiguration(ctx, configuration)
+}
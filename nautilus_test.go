@@ -3,10 +3,16 @@ package nautilus
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"sync/atomic"
 	"testing"
 	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/devmalloni/nautilus/x"
 )
 
 func TestNautilus_Run(t *testing.T) {
@@ -120,3 +126,1380 @@ func TestNautilus_TrySchedule(t *testing.T) {
 		t.Fatalf("Failed to register configurations: %v", err)
 	}
 }
+
+func TestNautilus_RecurringSchedule(t *testing.T) {
+	ctx := context.Background()
+
+	n := New(WithPayloadGenerator("heartbeat", func(ctx context.Context, r *HookRecurringSchedule, firedAt time.Time) (json.RawMessage, error) {
+		return json.RawMessage(`{"entity_id": "heartbeat"}`), nil
+	}))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_heartbeat",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{
+		ID:               "default",
+		HookDefinitionID: "on_heartbeat",
+		URL:              "http://localhost/webhook",
+		Tag:              Global,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	generator := "heartbeat"
+	recurring := &HookRecurringSchedule{
+		ID:                  "heartbeat",
+		HookConfigurationID: "default",
+		CronExpression:      "@hourly",
+		PayloadGenerator:    &generator,
+	}
+	err = n.RegisterRecurringSchedules(ctx, recurring)
+	if err != nil {
+		t.Fatalf("Failed to register recurring schedules: %v", err)
+	}
+
+	firedAt := recurring.NextRunAt
+	now := firedAt.Add(time.Second)
+	err = n.fireRecurringSchedule(ctx, recurring, now)
+	if err != nil {
+		t.Fatalf("Failed to fire recurring schedule: %v", err)
+	}
+
+	schedule, _, err := n.FindScheduleByID(ctx, fmt.Sprintf("heartbeat@%d", firedAt.Unix()))
+	if err != nil {
+		t.Fatalf("Expected firing to be scheduled, got %v", err)
+	}
+
+	if string(schedule.Payload) != `{"entity_id": "heartbeat"}` {
+		t.Errorf("Expected generated payload, got %s", schedule.Payload)
+	}
+
+	if !recurring.NextRunAt.After(now) {
+		t.Errorf("Expected next run to be after %v, got %v", now, recurring.NextRunAt)
+	}
+
+	// a firing delivered meanwhile is not overwritten when another instance fires it again
+	schedule.Status = HookScheduleStatusExecuted
+	err = n.persister.WriteHookSchedule(ctx, schedule)
+	if err != nil {
+		t.Fatalf("Failed to write schedule: %v", err)
+	}
+
+	recurring.NextRunAt = firedAt
+	err = n.fireRecurringSchedule(ctx, recurring, now)
+	if err != nil {
+		t.Fatalf("Failed to fire recurring schedule again: %v", err)
+	}
+
+	schedule, _, err = n.FindScheduleByID(ctx, schedule.ID)
+	if err != nil {
+		t.Fatalf("Failed to find schedule: %v", err)
+	}
+
+	if schedule.Status != HookScheduleStatusExecuted {
+		t.Errorf("Expected delivered firing to be kept, got %s", schedule.Status)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{
+		ID:               "default",
+		HookDefinitionID: "on_heartbeat",
+		URL:              "http://localhost/webhook",
+		Tag:              Global,
+		Disabled:         true,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	due, err := n.persister.FindDueHookRecurringSchedules(ctx, recurring.NextRunAt)
+	if err != nil || len(due) != 1 {
+		t.Fatalf("Expected recurring schedule to be due, got %d: %v", len(due), err)
+	}
+
+	disabledAt := due[0].NextRunAt
+	err = n.fireRecurringSchedule(ctx, due[0], disabledAt.Add(time.Second))
+	if err != nil {
+		t.Fatalf("Failed to fire recurring schedule: %v", err)
+	}
+
+	_, _, err = n.FindScheduleByID(ctx, fmt.Sprintf("heartbeat@%d", disabledAt.Unix()))
+	if err != ErrNotFound {
+		t.Errorf("Expected firing of a disabled configuration to be skipped, got %v", err)
+	}
+
+	if !due[0].NextRunAt.After(disabledAt) {
+		t.Errorf("Expected disabled recurring schedule to move to its next run, got %v", due[0].NextRunAt)
+	}
+}
+
+func TestNautilus_OrderingKey(t *testing.T) {
+	ctx := context.Background()
+
+	var received []string
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		data := HookExecutionData{}
+		json.NewDecoder(req.Body).Decode(&data)
+		received = append(received, data.ID)
+
+		res.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer func() { testServer.Close() }()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                    "on_updated",
+		HttpRequestMethod:     POST,
+		TotalAttempts:         1,
+		OrderingFailurePolicy: OrderingFailurePolicySkip,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{
+		ID:               "default",
+		HookDefinitionID: "on_updated",
+		URL:              testServer.URL,
+		Tag:              Global,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	first := n.MustSchedule(ctx, ID("first"), "on_updated", Global, json.RawMessage(`{}`), WithOrderingKey("entity"))
+	second := n.MustSchedule(ctx, ID("second"), "on_updated", Global, json.RawMessage(`{}`), WithOrderingKey("entity"))
+
+	// second is blocked while first is pending
+	if err := n.executeSchedule(ctx, second.ID); err != nil {
+		t.Fatalf("Failed to execute schedule: %v", err)
+	}
+	if len(received) != 0 {
+		t.Fatalf("Expected second schedule to be blocked, got deliveries %v", received)
+	}
+
+	// and backs off instead of being read again on every poll
+	blocked, _, err := n.FindScheduleByID(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("Failed to find schedule: %v", err)
+	}
+	if !blocked.NextAttempt().After(time.Now()) {
+		t.Errorf("Expected blocked schedule to back off, next attempt at %v", blocked.NextAttempt())
+	}
+
+	// first exhausts its attempts and is skipped by policy
+	for first.Status == HookScheduleStatusScheduled {
+		if err := n.executeSchedule(ctx, first.ID); err != nil {
+			t.Fatalf("Failed to execute schedule: %v", err)
+		}
+
+		first, _, err = n.FindScheduleByID(ctx, first.ID)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+	}
+
+	if err := n.executeSchedule(ctx, second.ID); err != nil {
+		t.Fatalf("Failed to execute schedule: %v", err)
+	}
+	if received[len(received)-1] != second.ID {
+		t.Fatalf("Expected second schedule to be delivered after first failed, got deliveries %v", received)
+	}
+}
+
+func TestNautilus_BatchDelivery(t *testing.T) {
+	ctx := context.Background()
+
+	var requests [][]HookExecutionData
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var batch []HookExecutionData
+		if err := json.NewDecoder(req.Body).Decode(&batch); err != nil {
+			t.Errorf("expected json array envelope, got %v", err)
+		}
+		requests = append(requests, batch)
+
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer func() { testServer.Close() }()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_tracked",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+		BatchMaxSize:      3,
+		BatchMaxWait:      time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{
+		ID:               "default",
+		HookDefinitionID: "on_tracked",
+		URL:              testServer.URL,
+		Tag:              Global,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	var schedules []*HookSchedule
+	for i := 0; i < 3; i++ {
+		schedules = append(schedules, n.MustScheduleAll(ctx, nil, "on_tracked", Global, json.RawMessage(`{}`))...)
+
+		if err := n.executeSchedule(ctx, schedules[i].ID); err != nil {
+			t.Fatalf("Failed to execute schedule: %v", err)
+		}
+	}
+
+	if len(requests) != 1 || len(requests[0]) != 3 {
+		t.Fatalf("Expected a single request with 3 events, got %v", requests)
+	}
+
+	for _, schedule := range schedules {
+		schedule, executions, err := n.FindScheduleByID(ctx, schedule.ID)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.Status != HookScheduleStatusExecuted || len(executions) != 1 || executions[0].BatchID == nil {
+			t.Errorf("Expected schedule %s to be executed within a batch", schedule.ID)
+		}
+	}
+}
+
+func TestNautilus_BatchDelivery_Members(t *testing.T) {
+	ctx := context.Background()
+
+	var requests [][]HookExecutionData
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var batch []HookExecutionData
+		json.NewDecoder(req.Body).Decode(&batch)
+		requests = append(requests, batch)
+
+		res.WriteHeader(http.StatusOK)
+	}))
+	defer testServer.Close()
+
+	persister := NewInMemoryPersister()
+	n := New(WithPersister(persister))
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_tracked", HttpRequestMethod: POST, TotalAttempts: 3, BatchMaxSize: 10})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_tracked", URL: testServer.URL, Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	first := n.MustSchedule(ctx, ID("first"), "on_tracked", Global, json.RawMessage(`{}`), WithOrderingKey("entity"))
+	blocked := n.MustSchedule(ctx, ID("blocked"), "on_tracked", Global, json.RawMessage(`{}`), WithOrderingKey("entity"))
+	retrying := n.MustSchedule(ctx, ID("retrying"), "on_tracked", Global, json.RawMessage(`{}`))
+	claimed := n.MustSchedule(ctx, ID("claimed"), "on_tracked", Global, json.RawMessage(`{}`))
+	member := n.MustSchedule(ctx, ID("member"), "on_tracked", Global, json.RawMessage(`{}`))
+
+	retrying.CurrentAttempt, retrying.NextAttemptAt = 1, x.NilTime(time.Now().Add(time.Hour))
+	err = persister.WriteHookSchedule(ctx, retrying)
+	if err != nil {
+		t.Fatalf("Failed to write schedule: %v", err)
+	}
+
+	// claimed by another instance
+	_, err = persister.ClaimHookSchedule(ctx, claimed.ID, "instance-2", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim schedule: %v", err)
+	}
+
+	if err := n.executeSchedule(ctx, first.ID); err != nil {
+		t.Fatalf("Failed to execute schedule: %v", err)
+	}
+
+	if len(requests) != 1 {
+		t.Fatalf("Expected a single batch, got %v", requests)
+	}
+
+	var delivered []string
+	for _, data := range requests[0] {
+		delivered = append(delivered, data.ID)
+	}
+	if len(delivered) != 2 || delivered[0] != first.ID || delivered[1] != member.ID {
+		t.Errorf("Expected only %s and %s to be batched, got %v", first.ID, member.ID, delivered)
+	}
+
+	for _, id := range []string{blocked.ID, retrying.ID, claimed.ID} {
+		schedule, _, err := n.FindScheduleByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.Status != HookScheduleStatusScheduled || schedule.CurrentAttempt > 1 {
+			t.Errorf("Expected schedule %s to be left out of the batch", id)
+		}
+	}
+}
+
+func TestNautilus_Broadcast(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_maintenance",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	for _, tag := range []HookConfigurationTag{"acme", "globex", "initech"} {
+		err = n.RegisterConfigurations(ctx, &HookConfiguration{
+			ID:               string(tag),
+			HookDefinitionID: "on_maintenance",
+			URL:              "http://localhost/webhook",
+			Tag:              tag,
+		})
+		if err != nil {
+			t.Fatalf("Failed to register configurations: %v", err)
+		}
+	}
+
+	groupID, err := n.Broadcast(ctx, "on_maintenance", json.RawMessage(`{}`), "acme", "globex")
+	if err != nil {
+		t.Fatalf("Failed to broadcast: %v", err)
+	}
+
+	status, err := n.FindGroupStatus(ctx, groupID)
+	if err != nil {
+		t.Fatalf("Failed to find group status: %v", err)
+	}
+
+	if status.Total != 2 || status.Statuses[HookScheduleStatusScheduled] != 2 {
+		t.Errorf("Expected 2 scheduled schedules, got %+v", status)
+	}
+}
+
+func TestNautilus_Schedule_MultipleConfigurations(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_created",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "crm", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	// the ID is kept with a single configuration
+	single, err := n.Schedule(ctx, ID("single"), "on_created", "acme", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if single.ID != "single" {
+		t.Errorf("Expected schedule ID to be kept, got %s", single.ID)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "warehouse", HookDefinitionID: "on_created", URL: "http://warehouse/webhook", Tag: "acme"},
+		&HookConfiguration{ID: "legacy", HookDefinitionID: "on_created", URL: "http://legacy/webhook", Tag: "acme", Disabled: true})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	schedules, err := n.ScheduleAll(ctx, ID("entity"), "on_created", "acme", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if len(schedules) != 2 {
+		t.Fatalf("Expected 2 schedules, got %d", len(schedules))
+	}
+
+	for _, schedule := range schedules {
+		if schedule.ID != ScheduleID("entity", schedule.HookConfigurationID) {
+			t.Errorf("Expected schedule ID derived from configuration, got %s", schedule.ID)
+		}
+	}
+
+	// the ID kept for a single configuration is still taken once others are added
+	_, err = n.ScheduleAll(ctx, ID("single"), "on_created", "acme", json.RawMessage(`{}`))
+	if err != ErrScheduleAlreadyExists {
+		t.Errorf("Expected already existing error, got %v", err)
+	}
+}
+
+func TestNautilus_Subscriptions(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx,
+		&HookDefinition{ID: "order.created", HttpRequestMethod: POST, TotalAttempts: 1},
+		&HookDefinition{ID: "order.updated", HttpRequestMethod: POST, TotalAttempts: 1},
+		&HookDefinition{ID: "customer.created", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterSubscriptions(ctx, &HookSubscription{
+		ID:                     "acme-erp",
+		Tag:                    "acme",
+		URL:                    "http://erp/webhook",
+		HookDefinitionPatterns: []string{"order.*"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register subscriptions: %v", err)
+	}
+
+	for _, definitionID := range []string{"order.created", "order.updated"} {
+		schedules, err := n.ScheduleAll(ctx, nil, definitionID, "acme", json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("Failed to schedule %s: %v", definitionID, err)
+		}
+
+		if len(schedules) != 1 || schedules[0].URL != "http://erp/webhook" {
+			t.Errorf("Expected %s to be scheduled to the subscription endpoint, got %v", definitionID, schedules)
+		}
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "customer.created", "acme", json.RawMessage(`{}`))
+	if err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a definition not covered by the subscription, got %v", err)
+	}
+
+	// configurations are reconciled when the subscription changes
+	err = n.RegisterSubscriptions(ctx, &HookSubscription{
+		ID:                     "acme-erp",
+		Tag:                    "acme-eu",
+		URL:                    "http://erp/webhook",
+		HookDefinitionPatterns: []string{"order.created", "invoice.*"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to register subscriptions: %v", err)
+	}
+
+	schedule, err := n.Schedule(ctx, nil, "order.created", "acme-eu", json.RawMessage(`{}`))
+	if err != nil || schedule.HookConfigurationID != "acme-erp:order.created" {
+		t.Errorf("Expected order.created to be scheduled to the new tag of the subscription, got %v", err)
+	}
+
+	_, err = n.Schedule(ctx, nil, "order.updated", "acme-eu", json.RawMessage(`{}`))
+	if err != ErrNotFound {
+		t.Errorf("Expected ErrNotFound for a definition the subscription no longer matches, got %v", err)
+	}
+
+	stale, err := n.persister.FindHookConfigurationByID(ctx, "acme-erp:order.updated")
+	if err != nil || !stale.Disabled {
+		t.Errorf("Expected stale configuration to be disabled, got %v", err)
+	}
+
+	// and when definitions are registered
+	err = n.RegisterDefinitions(ctx, &HookDefinition{ID: "invoice.paid", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	_, err = n.Schedule(ctx, nil, "invoice.paid", "acme-eu", json.RawMessage(`{}`))
+	if err != nil {
+		t.Errorf("Expected invoice.paid to be scheduled to the subscription endpoint, got %v", err)
+	}
+}
+
+func TestNautilus_TagFallback(t *testing.T) {
+	ctx := context.Background()
+
+	regions := map[HookConfigurationTag]HookConfigurationTag{"acme": "eu", "globex": "us"}
+	n := New(WithTagFallback(func(tag HookConfigurationTag) []HookConfigurationTag {
+		return []HookConfigurationTag{regions[tag], Global}
+	}))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: "http://global/webhook", Tag: Global},
+		&HookConfiguration{ID: "eu", HookDefinitionID: "on_created", URL: "http://eu/webhook", Tag: "eu"},
+		&HookConfiguration{ID: "initech", HookDefinitionID: "on_created", URL: "http://initech/webhook", Tag: "initech"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	expected := map[HookConfigurationTag]string{
+		"acme":    "eu",
+		"globex":  "default",
+		"initech": "initech",
+	}
+	for tag, configurationID := range expected {
+		schedules, err := n.ScheduleAll(ctx, nil, "on_created", tag, json.RawMessage(`{}`))
+		if err != nil {
+			t.Fatalf("Failed to schedule for %s: %v", tag, err)
+		}
+
+		if schedules[0].HookConfigurationID != configurationID {
+			t.Errorf("Expected %s to resolve to configuration %s, got %s", tag, configurationID, schedules[0].HookConfigurationID)
+		}
+
+		// schedules are listed under the requested tag, not the one they fell back to
+		listed, err := n.ListSchedulesOfTag(ctx, tag)
+		if err != nil {
+			t.Fatalf("Failed to list schedules of %s: %v", tag, err)
+		}
+
+		if len(listed) != 1 || listed[0].ID != schedules[0].ID {
+			t.Errorf("Expected schedule %s to be listed under %s, got %v", schedules[0].ID, tag, listed)
+		}
+	}
+}
+
+func TestNautilus_ScheduleMany(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_created",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "acme", HookDefinitionID: "on_created", URL: "http://acme/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	_, err = n.ScheduleAll(ctx, ID("existing"), "on_created", "acme", json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	results, err := n.ScheduleMany(ctx, []ScheduleRequest{
+		{ID: ID("first"), HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{}`)},
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{}`), Options: []func(*HookSchedule){WithPriority(5)}},
+		{ID: ID("existing"), HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{}`)},
+		{ID: ID("first"), HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{}`)},
+		{HookDefinitionID: "on_created", Tag: "unknown", Payload: json.RawMessage(`{}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule many: %v", err)
+	}
+
+	if len(results) != 5 {
+		t.Fatalf("Expected 5 results, got %d", len(results))
+	}
+
+	if results[0].Err != nil || len(results[0].Schedules) != 1 || results[0].Schedules[0].ID != "first" {
+		t.Errorf("Expected first request to be scheduled, got %+v", results[0])
+	}
+
+	if results[1].Err != nil || len(results[1].Schedules) != 1 || results[1].Schedules[0].Priority != 5 {
+		t.Errorf("Expected second request to be scheduled with options, got %+v", results[1])
+	}
+
+	if results[2].Err != ErrScheduleAlreadyExists {
+		t.Errorf("Expected already existing error, got %v", results[2].Err)
+	}
+
+	if results[3].Err != ErrScheduleAlreadyExists {
+		t.Errorf("Expected duplicated ID in batch error, got %v", results[3].Err)
+	}
+
+	if results[4].Err != ErrNotFound {
+		t.Errorf("Expected not found error, got %v", results[4].Err)
+	}
+
+	for _, id := range []string{"first", results[1].Schedules[0].ID} {
+		if _, _, err := n.FindScheduleByID(ctx, id); err != nil {
+			t.Errorf("Expected schedule %s to be written: %v", id, err)
+		}
+	}
+}
+
+func TestNautilus_ScheduleMany_Deduplication(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx,
+		&HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1, DeduplicationWindow: time.Hour},
+		&HookDefinition{ID: "on_updated", HttpRequestMethod: POST, TotalAttempts: 1, DebounceWindow: time.Hour, DeduplicationWindow: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "acme"},
+		&HookConfiguration{ID: "updated", HookDefinitionID: "on_updated", URL: "http://crm/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	// requests are checked against the earlier ones of the call, even along with an idempotent one
+	results, err := n.ScheduleMany(ctx, []ScheduleRequest{
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id":1}`)},
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id": 1}`)},
+		{HookDefinitionID: "on_updated", Tag: "acme", Payload: json.RawMessage(`{"name":"a"}`), Options: []func(*HookSchedule){WithCoalescingKey("entity-1")}},
+		{HookDefinitionID: "on_updated", Tag: "acme", Payload: json.RawMessage(`{"name":"b"}`), Options: []func(*HookSchedule){WithCoalescingKey("entity-1")}},
+		{HookDefinitionID: "on_updated", Tag: "acme", Payload: json.RawMessage(`{"name":"b"}`), Options: []func(*HookSchedule){WithCoalescingKey("entity-1")}},
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id":2}`), Options: []func(*HookSchedule){WithIdempotencyKey("created-2")}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule many: %v", err)
+	}
+
+	if results[0].Err != nil || len(results[0].Schedules) != 1 {
+		t.Fatalf("Expected first payload to be scheduled, got %+v", results[0])
+	}
+
+	if !errors.Is(results[1].Err, ErrDuplicatePayload) || len(results[1].Duplicates) != 1 || results[1].Duplicates[0].ID != results[0].Schedules[0].ID {
+		t.Errorf("Expected identical payload to duplicate the first one, got %+v", results[1])
+	}
+
+	if results[2].Err != nil || results[3].Err != nil || results[3].Schedules[0].ID != results[2].Schedules[0].ID {
+		t.Errorf("Expected payloads of the coalescing key to share a schedule, got %+v and %+v", results[2], results[3])
+	}
+
+	if !errors.Is(results[4].Err, ErrDuplicatePayload) {
+		t.Errorf("Expected payload held by the pending schedule to be a duplicate, got %+v", results[4])
+	}
+
+	if results[5].Err != nil || len(results[5].Schedules) != 1 {
+		t.Errorf("Expected idempotent request to be scheduled, got %+v", results[5])
+	}
+
+	created, err := n.persister.FindScheduledHookSchedulesOfConfiguration(ctx, "created", 10)
+	if err != nil {
+		t.Fatalf("Failed to find schedules: %v", err)
+	}
+
+	if len(created) != 2 {
+		t.Errorf("Expected 2 schedules written for created, got %d", len(created))
+	}
+
+	pending, err := n.persister.FindPendingHookScheduleByCoalescingKey(ctx, "updated", "entity-1")
+	if err != nil {
+		t.Fatalf("Failed to find pending schedule: %v", err)
+	}
+
+	if string(pending.Payload) != `{"name":"b"}` {
+		t.Errorf("Expected pending schedule to hold the latest payload, got %s", pending.Payload)
+	}
+}
+func TestNautilus_IdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                "on_created",
+		HttpRequestMethod: POST,
+		TotalAttempts:     1,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "crm", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "acme"},
+		&HookConfiguration{ID: "warehouse", HookDefinitionID: "on_created", URL: "http://warehouse/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	original, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"id": 1, "name": "a"}`), WithIdempotencyKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	retried, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"name":"a","id":1}`), WithIdempotencyKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule retry: %v", err)
+	}
+
+	if len(retried) != len(original) {
+		t.Fatalf("Expected %d schedules, got %d", len(original), len(retried))
+	}
+
+	for i := range original {
+		if retried[i].ID != original[i].ID {
+			t.Errorf("Expected original schedule %s, got %s", original[i].ID, retried[i].ID)
+		}
+
+		if retried[i].HookConfiguration == nil || retried[i].HookConfiguration.ID != original[i].HookConfigurationID {
+			t.Errorf("Expected original schedule %s to be returned with its configuration", original[i].ID)
+		}
+	}
+
+	// requests of the same key within a single write share the first schedule
+	results, err := n.ScheduleMany(ctx, []ScheduleRequest{
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id": 3}`), Options: []func(*HookSchedule){WithIdempotencyKey("entity-3")}},
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id": 3}`), Options: []func(*HookSchedule){WithIdempotencyKey("entity-3")}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule many: %v", err)
+	}
+
+	for i := range results[0].Schedules {
+		if results[1].Schedules[i].ID != results[0].Schedules[i].ID {
+			t.Errorf("Expected schedule %s to be shared, got %s", results[0].Schedules[i].ID, results[1].Schedules[i].ID)
+		}
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"id": 2}`), WithIdempotencyKey("entity-1"))
+	conflict, ok := err.(*IdempotencyConflictError)
+	if !ok {
+		t.Fatalf("Expected idempotency conflict error, got %v", err)
+	}
+
+	if conflict.IdempotencyKey != "entity-1" {
+		t.Errorf("Expected conflicting key entity-1, got %s", conflict.IdempotencyKey)
+	}
+
+	schedules, err := n.persister.FindHookSchedulesOfTag(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Failed to find schedules: %v", err)
+	}
+
+	if len(schedules) != 4 {
+		t.Errorf("Expected 4 schedules, got %d", len(schedules))
+	}
+}
+
+func TestNautilus_Debounce(t *testing.T) {
+	ctx := context.Background()
+
+	n := New(WithPayloadMerger("on_updated", func(ctx context.Context, pending, incoming json.RawMessage) (json.RawMessage, error) {
+		var p, i map[string]any
+		if err := json.Unmarshal(pending, &p); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(incoming, &i); err != nil {
+			return nil, err
+		}
+		for k, v := range i {
+			p[k] = v
+		}
+		return json.Marshal(p)
+	}))
+	err := n.RegisterDefinitions(ctx,
+		&HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1, DebounceWindow: time.Hour},
+		&HookDefinition{ID: "on_updated", HttpRequestMethod: POST, TotalAttempts: 1, DebounceWindow: time.Hour})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "created", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "acme"},
+		&HookConfiguration{ID: "updated", HookDefinitionID: "on_updated", URL: "http://crm/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	first, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"name":"a"}`), WithCoalescingKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	replaced, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"name":"b"}`), WithCoalescingKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if replaced[0].ID != first[0].ID || string(replaced[0].Payload) != `{"name":"b"}` {
+		t.Errorf("Expected pending schedule with replaced payload, got %s %s", replaced[0].ID, replaced[0].Payload)
+	}
+
+	other, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"name":"c"}`), WithCoalescingKey("entity-2"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if other[0].ID == first[0].ID {
+		t.Error("Expected a different coalescing key to create a new schedule")
+	}
+
+	if !replaced[0].IsDebouncing(time.Now()) {
+		t.Error("Expected schedule to wait for the debounce window")
+	}
+
+	if replaced[0].IsDebouncing(replaced[0].CreatedAt.Add(10 * time.Hour)) {
+		t.Error("Expected schedule to stop waiting after the debounce max wait")
+	}
+
+	_, err = n.persister.(HookScheduleClaimer).ClaimHookSchedule(ctx, other[0].ID, "worker", time.Minute)
+	if err != nil {
+		t.Fatalf("Failed to claim schedule: %v", err)
+	}
+
+	unclaimed, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"name":"d"}`), WithCoalescingKey("entity-2"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if unclaimed[0].ID == other[0].ID {
+		t.Error("Expected the payload of a claimed schedule not to be replaced")
+	}
+
+	err = n.executeSchedule(ctx, first[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to execute schedule: %v", err)
+	}
+
+	schedule, executions, err := n.FindScheduleByID(ctx, first[0].ID)
+	if err != nil {
+		t.Fatalf("Failed to find schedule: %v", err)
+	}
+
+	if schedule.CurrentAttempt != 0 || len(executions) != 0 {
+		t.Errorf("Expected schedule not to be delivered within the debounce window")
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "on_updated", "acme", json.RawMessage(`{"name":"a"}`), WithCoalescingKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	merged, err := n.ScheduleAll(ctx, nil, "on_updated", "acme", json.RawMessage(`{"age":1}`), WithCoalescingKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	if string(merged[0].Payload) != `{"age":1,"name":"a"}` {
+		t.Errorf("Expected merged payload, got %s", merged[0].Payload)
+	}
+}
+
+func TestNautilus_ScheduleTx(t *testing.T) {
+	ctx := context.Background()
+
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	n := New(WithPersister(persister))
+
+	expectConfigurations := func(debounceWindow time.Duration) {
+		mock.ExpectQuery(`SELECT \* FROM hook_configurations WHERE hook_definition_id = \$1 AND tag = \$2`).
+			WithArgs("on_created", "acme").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "hook_definition_id", "tag", "url"}).
+				AddRow("crm", "on_created", "acme", "http://crm/webhook"))
+		mock.ExpectQuery(`SELECT \* FROM hook_definitions WHERE id = \$1`).
+			WithArgs("on_created").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "http_request_method", "total_attempts", "debounce_window"}).
+				AddRow("on_created", "POST", 1, int64(debounceWindow)))
+	}
+
+	// the schedules are written with the caller data, and committed or rolled back along with it
+	for _, commit := range []bool{true, false} {
+		mock.ExpectBegin()
+		mock.ExpectExec(`INSERT INTO orders`).WillReturnResult(sqlmock.NewResult(1, 1))
+		expectConfigurations(0)
+		mock.ExpectExec(`INSERT INTO hook_schedules`).WillReturnResult(sqlmock.NewResult(1, 1))
+		if commit {
+			mock.ExpectCommit()
+		} else {
+			mock.ExpectRollback()
+		}
+
+		tx, err := persister.db.BeginTxx(ctx, nil)
+		if err != nil {
+			t.Fatalf("Failed to begin transaction: %v", err)
+		}
+
+		_, err = tx.ExecContext(ctx, "INSERT INTO orders (id) VALUES ($1)", "order-1")
+		if err != nil {
+			t.Fatalf("Failed to insert order: %v", err)
+		}
+
+		schedules, err := n.ScheduleTx(ctx, tx, nil, "on_created", "acme", json.RawMessage(`{"order_id":"order-1"}`))
+		if err != nil {
+			t.Fatalf("Failed to schedule within transaction: %v", err)
+		}
+
+		if len(schedules) != 1 || schedules[0].HookConfigurationID != "crm" {
+			t.Errorf("Expected a schedule of crm, got %+v", schedules)
+		}
+
+		if commit {
+			err = tx.Commit()
+		} else {
+			err = tx.Rollback()
+		}
+		if err != nil {
+			t.Fatalf("Failed to end transaction: %v", err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Fatalf("Unmet expectations with commit %t: %v", commit, err)
+		}
+	}
+
+	// the pending schedule of a coalescing key would be replaced outside of the transaction
+	mock.ExpectBegin()
+	expectConfigurations(time.Minute)
+	mock.ExpectRollback()
+
+	tx, err := persister.db.BeginTxx(ctx, nil)
+	if err != nil {
+		t.Fatalf("Failed to begin transaction: %v", err)
+	}
+
+	_, err = n.ScheduleTx(ctx, tx, nil, "on_created", "acme", json.RawMessage(`{}`), WithCoalescingKey("order-1"))
+	if err != ErrCoalescingInTx {
+		t.Errorf("Expected coalescing within a transaction to fail, got %v", err)
+	}
+	tx.Rollback()
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("Unmet expectations: %v", err)
+	}
+}
+
+func TestNautilus_Deduplication(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                  "on_created",
+		HttpRequestMethod:   POST,
+		TotalAttempts:       1,
+		DeduplicationWindow: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "crm", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	original, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"id": 1, "tags": ["a", "b"]}`))
+	if err != nil {
+		t.Fatalf("Failed to schedule: %v", err)
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"tags":["a","b"],"id":1}`))
+	duplicate, ok := err.(*DuplicatePayloadError)
+	if !ok {
+		t.Fatalf("Expected duplicate payload error, got %v", err)
+	}
+
+	if len(duplicate.Originals) != 1 || duplicate.Originals[0].ID != original[0].ID {
+		t.Errorf("Expected duplicate of %s, got %+v", original[0].ID, duplicate.Originals)
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"tags":["b","a"],"id":1}`))
+	if err != nil {
+		t.Errorf("Expected different payload to be scheduled, got %v", err)
+	}
+
+	results, err := n.ScheduleMany(ctx, []ScheduleRequest{
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id":1,"tags":["a","b"]}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule many: %v", err)
+	}
+
+	if !errors.Is(results[0].Err, ErrDuplicatePayload) {
+		t.Errorf("Expected duplicate payload error, got %v", results[0].Err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "erp", HookDefinitionID: "on_created", URL: "http://erp/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	// a payload dropped for some configurations only is not an error
+	partial := n.MustScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"id":1,"tags":["a","b"]}`))
+	if len(partial) != 1 || partial[0].HookConfigurationID != "erp" {
+		t.Errorf("Expected the schedule of the other configuration to be written, got %+v", partial)
+	}
+
+	results, err = n.ScheduleMany(ctx, []ScheduleRequest{
+		{HookDefinitionID: "on_created", Tag: "acme", Payload: json.RawMessage(`{"id":1,"tags":["b","a"]}`)},
+	})
+	if err != nil {
+		t.Fatalf("Failed to schedule many: %v", err)
+	}
+
+	if results[0].Err != nil || len(results[0].Schedules) != 1 || results[0].Schedules[0].HookConfigurationID != "erp" {
+		t.Errorf("Expected the schedule of the other configuration to be written, got %+v", results[0])
+	}
+
+	if len(results[0].Duplicates) != 1 || results[0].Duplicates[0].HookConfigurationID != "crm" {
+		t.Errorf("Expected the payload to be dropped for crm only, got %+v", results[0].Duplicates)
+	}
+
+	original[0].Status = HookScheduleStatusQuarantined
+	err = n.persister.WriteHookSchedule(ctx, original[0])
+	if err != nil {
+		t.Fatalf("Failed to write schedule: %v", err)
+	}
+
+	schedules, err := n.ScheduleAll(ctx, nil, "on_created", "acme", json.RawMessage(`{"id":1,"tags":["a","b"]}`))
+	if err != nil || len(schedules) != 1 || schedules[0].HookConfigurationID != "crm" {
+		t.Errorf("Expected a quarantined schedule not to be a duplicate, got %+v, %v", schedules, err)
+	}
+}
+
+func TestNautilus_DeduplicationOfCoalescedPayload(t *testing.T) {
+	ctx := context.Background()
+
+	n := New()
+	err := n.RegisterDefinitions(ctx, &HookDefinition{
+		ID:                  "on_updated",
+		HttpRequestMethod:   POST,
+		TotalAttempts:       1,
+		DebounceWindow:      time.Hour,
+		DeduplicationWindow: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx,
+		&HookConfiguration{ID: "crm", HookDefinitionID: "on_updated", URL: "http://crm/webhook", Tag: "acme"})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	for _, payload := range []string{`{"name":"a"}`, `{"name":"b"}`} {
+		_, err = n.ScheduleAll(ctx, nil, "on_updated", "acme", json.RawMessage(payload), WithCoalescingKey("entity-1"))
+		if err != nil {
+			t.Fatalf("Failed to schedule %s: %v", payload, err)
+		}
+	}
+
+	// the pending schedule now holds b, so a is not a duplicate anymore
+	schedules, err := n.ScheduleAll(ctx, nil, "on_updated", "acme", json.RawMessage(`{"name":"a"}`), WithCoalescingKey("entity-1"))
+	if err != nil {
+		t.Fatalf("Expected superseded payload to be scheduled again, got %v", err)
+	}
+
+	if string(schedules[0].Payload) != `{"name":"a"}` {
+		t.Errorf("Expected pending schedule to hold the latest payload, got %s", schedules[0].Payload)
+	}
+
+	_, err = n.ScheduleAll(ctx, nil, "on_updated", "acme", json.RawMessage(`{"name":"a"}`), WithCoalescingKey("entity-1"))
+	if !errors.Is(err, ErrDuplicatePayload) {
+		t.Errorf("Expected the pending payload to be a duplicate, got %v", err)
+	}
+}
+
+func TestNautilus_ClaimSchedules(t *testing.T) {
+	ctx := context.Background()
+
+	persister := NewInMemoryPersister()
+	n := New(WithPersister(persister))
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 3})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		n.MustSchedule(ctx, nil, "on_created", Global, json.RawMessage(`{}`))
+	}
+
+	instances := []*PollScheduler{
+		NewPollScheduler(persister, WithInstanceID("instance-1"), WithPageSize(2)),
+		NewPollScheduler(persister, WithInstanceID("instance-2"), WithPageSize(2)),
+	}
+
+	// the first instance only claims what its workers can take
+	instances[0].SetCapacity(func() int { return 1 })
+
+	claimed := map[string]string{}
+	for _, instance := range instances {
+		schedules, _, err := instance.findSchedules(ctx, time.Now().UTC(), nil)
+		if err != nil {
+			t.Fatalf("Failed to claim schedules: %v", err)
+		}
+
+		if instance == instances[0] && len(schedules) != 1 {
+			t.Errorf("Expected claims to be limited by the capacity, got %d", len(schedules))
+		}
+
+		for _, schedule := range schedules {
+			if owner, ok := claimed[schedule.ID]; ok {
+				t.Errorf("Schedule %s claimed by both %s and %s", schedule.ID, owner, instance.instanceID)
+			}
+			claimed[schedule.ID] = instance.instanceID
+		}
+	}
+
+	if len(claimed) != 3 {
+		t.Fatalf("Expected 3 claimed schedules, got %d", len(claimed))
+	}
+
+	// a failed attempt releases the claim, and the retry waits for its next attempt
+	for id := range claimed {
+		schedule, _, err := n.FindScheduleByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		now := time.Now().UTC()
+		schedule.CurrentAttempt, schedule.UpdatedAt = 1, &now
+		n.scheduleRetry(schedule)
+		err = persister.WriteHookSchedule(ctx, schedule)
+		if err != nil {
+			t.Fatalf("Failed to write schedule: %v", err)
+		}
+
+		schedule, _, err = n.FindScheduleByID(ctx, id)
+		if err != nil {
+			t.Fatalf("Failed to find schedule: %v", err)
+		}
+
+		if schedule.ClaimedBy != nil {
+			t.Errorf("Expected claim of %s to be released", id)
+		}
+	}
+
+	schedules, _, err := instances[0].findSchedules(ctx, time.Now().UTC(), nil)
+	if err != nil {
+		t.Fatalf("Failed to claim schedules: %v", err)
+	}
+
+	if len(schedules) != 0 {
+		t.Errorf("Expected recently attempted schedules not to be claimed, got %d", len(schedules))
+	}
+}
+
+func TestNautilus_PollDueSchedules(t *testing.T) {
+	ctx := context.Background()
+
+	persister := NewInMemoryPersister()
+	n := New(WithPersister(persister))
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 3})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: "http://crm/webhook", Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	for i := 0; i < 5; i++ {
+		n.MustSchedule(ctx, nil, "on_created", Global, json.RawMessage(`{}`))
+	}
+
+	// a failed attempt is not due until its retry
+	schedules, err := persister.FindDueHookSchedules(ctx, time.Now().UTC(), nil, 0)
+	if err != nil {
+		t.Fatalf("Failed to find due schedules: %v", err)
+	}
+
+	retried := schedules[0]
+	retried.CurrentAttempt = 1
+	retried.UpdatedAt = x.NilTime(time.Now().UTC())
+	retried.NextAttemptAt = x.NilTime(time.Now().UTC().Add(time.Hour))
+	err = persister.WriteHookSchedule(ctx, retried)
+	if err != nil {
+		t.Fatalf("Failed to write schedule: %v", err)
+	}
+
+	// an urgent schedule is polled first, although it is the last one due
+	urgent := n.MustSchedule(ctx, nil, "on_created", Global, json.RawMessage(`{}`), WithPriority(10))
+
+	// hide the claimer, so the scheduler pages with the cursor
+	scheduler := NewPollScheduler(struct{ HookScheduleReader }{persister}, WithPageSize(2))
+	scheduleCh := make(chan *HookSchedule, 10)
+	scheduler.poll(ctx, scheduleCh, nil)
+	close(scheduleCh)
+
+	var first *HookSchedule
+	polled := map[string]bool{}
+	for schedule := range scheduleCh {
+		if first == nil {
+			first = schedule
+		}
+		if polled[schedule.ID] {
+			t.Errorf("Schedule %s polled twice", schedule.ID)
+		}
+		polled[schedule.ID] = true
+	}
+
+	if len(polled) != 5 {
+		t.Errorf("Expected 5 due schedules, got %d", len(polled))
+	}
+
+	if first == nil || first.ID != urgent.ID {
+		t.Errorf("Expected urgent schedule %s to be polled first", urgent.ID)
+	}
+
+	if polled[retried.ID] {
+		t.Errorf("Expected schedule %s not to be due", retried.ID)
+	}
+}
+
+func TestNautilus_Run_DispatchOnce(t *testing.T) {
+	calls := make(chan struct{}, 100)
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls <- struct{}{}
+		<-time.After(300 * time.Millisecond)
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithWorkersCount(5),
+		// without claims, every poll reads the schedule still being delivered
+		WithScheduler(NewPollScheduler(struct{ HookScheduleReader }{persister},
+			WithRunnerInterval(10*time.Millisecond))))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 1})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: testServer.URL, Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	go n.Run(ctx)
+
+	n.MustSchedule(ctx, nil, "on_created", Global, json.RawMessage(`{}`))
+
+	<-time.After(600 * time.Millisecond)
+
+	if len(calls) != 1 {
+		t.Errorf("Expected webhook to be called once, got %d", len(calls))
+	}
+}
+
+func TestNautilus_PushScheduler_BatchMaxWait(t *testing.T) {
+	calls := make(chan []HookExecutionData, 10)
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		var batch []HookExecutionData
+		json.NewDecoder(req.Body).Decode(&batch)
+		calls <- batch
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// the sweep runs every minute, so the batch is only delivered if it is pushed again
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithScheduler(NewPushScheduler(persister)))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_tracked", HttpRequestMethod: POST, TotalAttempts: 1, BatchMaxSize: 10, BatchMaxWait: 200 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_tracked", URL: testServer.URL, Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	go n.Run(ctx)
+	<-time.After(10 * time.Millisecond)
+
+	scheduledAt := time.Now()
+	n.MustSchedule(ctx, ID("first"), "on_tracked", Global, json.RawMessage(`{}`))
+	n.MustSchedule(ctx, ID("second"), "on_tracked", Global, json.RawMessage(`{}`))
+
+	select {
+	case batch := <-calls:
+		if len(batch) != 2 {
+			t.Errorf("Expected a batch of 2 events, got %d", len(batch))
+		}
+		if waited := time.Since(scheduledAt); waited < 150*time.Millisecond {
+			t.Errorf("Expected batch to wait for members, delivered after %v", waited)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Expected batch to be delivered once it waited for BatchMaxWait")
+	}
+}
+
+func TestNautilus_PushScheduler(t *testing.T) {
+	calls := make(chan time.Time, 10)
+	failed := atomic.Bool{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		calls <- time.Now()
+		if failed.CompareAndSwap(false, true) {
+			res.WriteHeader(500)
+			return
+		}
+		res.WriteHeader(200)
+	}))
+	defer testServer.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := NewInMemoryPersister()
+	n := New(
+		WithPersister(persister),
+		WithRetryInterval(200*time.Millisecond),
+		WithScheduler(NewPushScheduler(persister)))
+
+	err := n.RegisterDefinitions(ctx, &HookDefinition{ID: "on_created", HttpRequestMethod: POST, TotalAttempts: 3})
+	if err != nil {
+		t.Fatalf("Failed to register definitions: %v", err)
+	}
+
+	err = n.RegisterConfigurations(ctx, &HookConfiguration{ID: "default", HookDefinitionID: "on_created", URL: testServer.URL, Tag: Global})
+	if err != nil {
+		t.Fatalf("Failed to register configurations: %v", err)
+	}
+
+	go n.Run(ctx)
+	<-time.After(10 * time.Millisecond)
+
+	scheduledAt := time.Now()
+	n.MustSchedule(ctx, ID("pushed"), "on_created", Global, json.RawMessage(`{}`))
+
+	var attempts []time.Time
+	for len(attempts) < 2 {
+		select {
+		case at := <-calls:
+			attempts = append(attempts, at)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("Expected 2 attempts, got %d", len(attempts))
+		}
+	}
+
+	if attempts[0].Sub(scheduledAt) > 100*time.Millisecond {
+		t.Errorf("Expected immediate dispatch, took %v", attempts[0].Sub(scheduledAt))
+	}
+
+	if retry := attempts[1].Sub(attempts[0]); retry < 150*time.Millisecond || retry > time.Second {
+		t.Errorf("Expected retry after the retry interval, took %v", retry)
+	}
+}
@@ -2,11 +2,16 @@ package nautilus
 
 import (
 	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
+	"time"
 )
 
 var (
-	ErrNotFound = errors.New("record not found")
+	ErrNotFound       = errors.New("record not found")
+	ErrTxNotSupported = errors.New("persister does not support caller transactions")
+	ErrClaimLost      = errors.New("schedule claim was lost")
 )
 
 type (
@@ -14,15 +19,75 @@ type (
 		FindHookSchedulesByID(ctx context.Context, id string) (*HookSchedule, []*HookExecution, error)
 		FindHookSchedulesOfTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSchedule, error)
 		FindScheduledHookSchedules(ctx context.Context) ([]*HookSchedule, error)
+		// FindDeadLetterHookSchedules returns the failed and quarantined schedules matching the
+		// filter, ordered by failed time.
+		FindDeadLetterHookSchedules(ctx context.Context, filter DeadLetterFilter) ([]*HookSchedule, error)
+		// FindDueHookSchedules returns up to limit scheduled schedules due at now with their
+		// configuration, ordered by priority, next attempt and ID, starting after the given
+		// schedule when paging.
+		FindDueHookSchedules(ctx context.Context, now time.Time, after *HookSchedule, limit int) ([]*HookSchedule, error)
+		FindHookSchedulePredecessors(ctx context.Context, s *HookSchedule) ([]*HookSchedule, error)
+		FindScheduledHookSchedulesOfConfiguration(ctx context.Context, hookConfigurationID string, limit int) ([]*HookSchedule, error)
+		FindHookSchedulesByGroupID(ctx context.Context, groupID string) ([]*HookSchedule, error)
+		FindExistingHookScheduleIDs(ctx context.Context, ids []string) ([]string, error)
+		// FindPendingHookScheduleByCoalescingKey returns the newest scheduled and not yet attempted
+		// schedule of the configuration with the coalescing key, or ErrNotFound.
+		FindPendingHookScheduleByCoalescingKey(ctx context.Context, hookConfigurationID string, coalescingKey string) (*HookSchedule, error)
+		// FindHookScheduleByPayloadHash returns the oldest scheduled or executed schedule of the
+		// configuration with the payload hash created since the given time, or ErrNotFound.
+		FindHookScheduleByPayloadHash(ctx context.Context, hookConfigurationID string, payloadHash string, since time.Time) (*HookSchedule, error)
 	}
 
+	// HookScheduleWriter writes schedules. Schedules with a claim are only written while the
+	// stored schedule is still claimed by it, otherwise the whole write fails with ErrClaimLost,
+	// so an execution whose lease ran out does not overwrite the result of another.
 	HookScheduleWriter interface {
 		WriteHookSchedule(ctx context.Context, c *HookSchedule, e ...*HookExecution) error
+		WriteHookSchedules(ctx context.Context, c []*HookSchedule, e ...*HookExecution) error
+		// WriteIdempotentHookSchedules inserts the schedules, returning instead the stored ones
+		// of idempotency keys already used by their configurations, including by earlier schedules
+		// of c. Stored schedules are returned with the configuration of the schedule they replace.
+		// A stored schedule with a different payload fails the whole write with an
+		// *IdempotencyConflictError, and a taken ID with ErrScheduleAlreadyExists.
+		WriteIdempotentHookSchedules(ctx context.Context, c []*HookSchedule) ([]*HookSchedule, error)
+		// ReplacePendingHookSchedulePayload replaces the payload of a schedule, along with its hash,
+		// as long as it was not attempted yet nor is claimed, e.g while it is delivered, reporting
+		// whether it was replaced.
+		ReplacePendingHookSchedulePayload(ctx context.Context, id string, payload json.RawMessage, payloadHash *string, updatedAt time.Time) (bool, error)
+		// ReplaceHookSchedulesURL changes the URL the schedules are delivered to, e.g when they are
+		// requeued to an endpoint that moved. Other writes keep the URL of existing schedules.
+		ReplaceHookSchedulesURL(ctx context.Context, ids []string, url string) error
+	}
+
+	// SqlTx is a caller transaction, satisfied by both *sql.Tx and *sqlx.Tx.
+	SqlTx interface {
+		ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	}
+
+	HookScheduleTxWriter interface {
+		WriteHookSchedulesTx(ctx context.Context, tx SqlTx, c []*HookSchedule) error
+	}
+
+	// HookScheduleClaimer leases scheduled schedules to a single instance, so instances
+	// sharing the persister do not deliver the same attempt. Writing a schedule releases its claim.
+	HookScheduleClaimer interface {
+		// ClaimScheduledHookSchedules claims up to limit due schedules whose claims are not leased,
+		// returned like FindDueHookSchedules.
+		ClaimScheduledHookSchedules(ctx context.Context, claimedBy string, lease time.Duration, limit int) ([]*HookSchedule, error)
+		// ClaimHookSchedule claims a single scheduled schedule, returned with its configuration,
+		// or returns ErrNotFound if it is not scheduled or its claim is leased.
+		ClaimHookSchedule(ctx context.Context, id string, claimedBy string, lease time.Duration) (*HookSchedule, error)
+		// ClaimHookSchedules claims the scheduled schedules among ids whose claims are not leased,
+		// or are held by heldBy, and returns them. Schedules claimed elsewhere are left out.
+		ClaimHookSchedules(ctx context.Context, ids []string, claimedBy string, heldBy *string, lease time.Duration) ([]*HookSchedule, error)
+		ReleaseHookScheduleClaim(ctx context.Context, id string) error
 	}
 
 	HookConfigurationReader interface {
-		FindHookConfiguration(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) (*HookConfiguration, error)
+		FindActiveHookConfigurations(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) ([]*HookConfiguration, error)
 		FindHookConfigurationsByTag(ctx context.Context, tag HookConfigurationTag) ([]*HookConfiguration, error)
+		FindHookConfigurationByID(ctx context.Context, id string) (*HookConfiguration, error)
+		FindHookConfigurationsByDefinitionID(ctx context.Context, hookDefinitionID string) ([]*HookConfiguration, error)
 		FindHookConfigurations(ctx context.Context) ([]*HookConfiguration, error)
 	}
 
@@ -39,6 +104,24 @@ type (
 		WriteHookDefinitions(ctx context.Context, d ...*HookDefinition) error
 	}
 
+	HookRecurringScheduleReader interface {
+		FindHookRecurringSchedules(ctx context.Context) ([]*HookRecurringSchedule, error)
+		FindDueHookRecurringSchedules(ctx context.Context, now time.Time) ([]*HookRecurringSchedule, error)
+	}
+
+	HookRecurringScheduleWriter interface {
+		WriteHookRecurringSchedule(ctx context.Context, r *HookRecurringSchedule) error
+	}
+
+	HookSubscriptionReader interface {
+		FindHookSubscriptionsByTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSubscription, error)
+		FindHookSubscriptions(ctx context.Context) ([]*HookSubscription, error)
+	}
+
+	HookSubscriptionWriter interface {
+		WriteHookSubscription(ctx context.Context, s *HookSubscription) error
+	}
+
 	NautilusPersister interface {
 		HookScheduleReader
 		HookScheduleWriter
@@ -48,5 +131,11 @@ type (
 
 		HookDefinitionReader
 		HookDefinitionWriter
+
+		HookRecurringScheduleReader
+		HookRecurringScheduleWriter
+
+		HookSubscriptionReader
+		HookSubscriptionWriter
 	}
 )
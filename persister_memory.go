@@ -2,7 +2,11 @@ package nautilus
 
 import (
 	"context"
+	"encoding/json"
+	"slices"
+	"sort"
 	"sync"
+	"time"
 )
 
 type InMemoryPersister struct {
@@ -11,6 +15,9 @@ type InMemoryPersister struct {
 	schedules      map[string]*HookSchedule
 	configurations map[string]*HookConfiguration
 	executions     map[string][]*HookExecution
+	recurring      map[string]*HookRecurringSchedule
+	subscriptions  map[string]*HookSubscription
+	due            *dueIndex
 }
 
 func NewInMemoryPersister() *InMemoryPersister {
@@ -20,6 +27,9 @@ func NewInMemoryPersister() *InMemoryPersister {
 		schedules:      make(map[string]*HookSchedule),
 		configurations: make(map[string]*HookConfiguration),
 		executions:     make(map[string][]*HookExecution),
+		recurring:      make(map[string]*HookRecurringSchedule),
+		subscriptions:  make(map[string]*HookSubscription),
+		due:            newDueIndex(),
 	}
 }
 
@@ -30,9 +40,9 @@ func (p *InMemoryPersister) FindHookSchedulesByID(ctx context.Context, id string
 	if !ok {
 		return nil, nil, ErrNotFound
 	}
-	executions := p.executions[id]
+	executions := slices.Clone(p.executions[id])
 
-	return s, executions, nil
+	return copySchedule(s), executions, nil
 }
 
 func (p *InMemoryPersister) FindHookSchedulesOfTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSchedule, error) {
@@ -41,8 +51,8 @@ func (p *InMemoryPersister) FindHookSchedulesOfTag(ctx context.Context, tag Hook
 
 	var res []*HookSchedule
 	for _, v := range p.schedules {
-		if v.HookConfiguration.Tag == tag {
-			res = append(res, v)
+		if v.Tag == tag {
+			res = append(res, copySchedule(v))
 		}
 	}
 
@@ -56,7 +66,288 @@ func (p *InMemoryPersister) FindScheduledHookSchedules(ctx context.Context) ([]*
 	var res []*HookSchedule
 	for _, v := range p.schedules {
 		if v.Status == HookScheduleStatusScheduled {
-			res = append(res, v)
+			res = append(res, copySchedule(v))
+		}
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) ClaimScheduledHookSchedules(ctx context.Context, claimedBy string, lease time.Duration, limit int) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	now := time.Now().UTC()
+	var res []*HookSchedule
+	for _, id := range p.due.due(now, nil, 0) {
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+
+		v := p.schedules[id]
+		if v.ClaimedUntil != nil && !v.ClaimedUntil.Before(now) {
+			continue
+		}
+
+		res = append(res, v)
+	}
+
+	claimedUntil := now.Add(lease)
+	for i, v := range res {
+		v.ClaimedBy = &claimedBy
+		v.ClaimedUntil = &claimedUntil
+		res[i] = p.copyWithConfiguration(v)
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) ClaimHookSchedule(ctx context.Context, id string, claimedBy string, lease time.Duration) (*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	now := time.Now().UTC()
+	v, ok := p.schedules[id]
+	if !ok || v.Status != HookScheduleStatusScheduled || (v.ClaimedUntil != nil && !v.ClaimedUntil.Before(now)) {
+		return nil, ErrNotFound
+	}
+
+	claimedUntil := now.Add(lease)
+	v.ClaimedBy = &claimedBy
+	v.ClaimedUntil = &claimedUntil
+
+	return p.copyWithConfiguration(v), nil
+}
+
+func (p *InMemoryPersister) ClaimHookSchedules(ctx context.Context, ids []string, claimedBy string, heldBy *string, lease time.Duration) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	now := time.Now().UTC()
+	claimedUntil := now.Add(lease)
+	var res []*HookSchedule
+	for _, id := range ids {
+		v, ok := p.schedules[id]
+		if !ok || v.Status != HookScheduleStatusScheduled {
+			continue
+		}
+
+		leased := v.ClaimedUntil != nil && !v.ClaimedUntil.Before(now)
+		held := heldBy != nil && v.ClaimedBy != nil && *v.ClaimedBy == *heldBy
+		if leased && !held {
+			continue
+		}
+
+		v.ClaimedBy = &claimedBy
+		v.ClaimedUntil = &claimedUntil
+		res = append(res, copySchedule(v))
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) ReleaseHookScheduleClaim(ctx context.Context, id string) error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if v, ok := p.schedules[id]; ok {
+		v.ClaimedBy, v.ClaimedUntil = nil, nil
+	}
+
+	return nil
+}
+
+func (p *InMemoryPersister) FindDeadLetterHookSchedules(ctx context.Context, filter DeadLetterFilter) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookSchedule
+	for _, v := range p.schedules {
+		if !v.IsDeadLetter() {
+			continue
+		}
+
+		configuration, ok := p.configurations[v.HookConfigurationID]
+		if !ok {
+			continue
+		}
+
+		if filter.HookDefinitionID != nil && configuration.HookDefinitionID != *filter.HookDefinitionID {
+			continue
+		}
+
+		if filter.Tag != nil && v.Tag != *filter.Tag {
+			continue
+		}
+
+		failedAt := lastUpdateOf(v)
+		if (filter.FailedAfter != nil && failedAt.Before(*filter.FailedAfter)) ||
+			(filter.FailedBefore != nil && !failedAt.Before(*filter.FailedBefore)) {
+			continue
+		}
+
+		res = append(res, copySchedule(v))
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if !lastUpdateOf(res[i]).Equal(lastUpdateOf(res[j])) {
+			return lastUpdateOf(res[i]).Before(lastUpdateOf(res[j]))
+		}
+		return res[i].ID < res[j].ID
+	})
+
+	if filter.Limit > 0 && len(res) > filter.Limit {
+		res = res[:filter.Limit]
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindDueHookSchedules(ctx context.Context, now time.Time, after *HookSchedule, limit int) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var afterKey *dueKey
+	if after != nil {
+		afterKey = &dueKey{priority: after.Priority, at: after.NextAttempt(), id: after.ID}
+	}
+
+	var res []*HookSchedule
+	for _, id := range p.due.due(now, afterKey, limit) {
+		res = append(res, p.copyWithConfiguration(p.schedules[id]))
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindHookSchedulePredecessors(ctx context.Context, s *HookSchedule) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	if s.OrderingKey == nil {
+		return nil, nil
+	}
+
+	var res []*HookSchedule
+	for _, v := range p.schedules {
+		if v.ID == s.ID ||
+			v.HookConfigurationID != s.HookConfigurationID ||
+			v.OrderingKey == nil ||
+			*v.OrderingKey != *s.OrderingKey {
+			continue
+		}
+
+		if v.CreatedAt.Before(s.CreatedAt) || (v.CreatedAt.Equal(s.CreatedAt) && v.ID < s.ID) {
+			res = append(res, copySchedule(v))
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindScheduledHookSchedulesOfConfiguration(ctx context.Context, hookConfigurationID string, limit int) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookSchedule
+	for _, v := range p.schedules {
+		if v.HookConfigurationID == hookConfigurationID && v.Status == HookScheduleStatusScheduled {
+			res = append(res, copySchedule(v))
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	if limit > 0 && len(res) > limit {
+		res = res[:limit]
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindPendingHookScheduleByCoalescingKey(ctx context.Context, hookConfigurationID string, coalescingKey string) (*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res *HookSchedule
+	for _, v := range p.schedules {
+		if v.HookConfigurationID != hookConfigurationID ||
+			v.CoalescingKey == nil || *v.CoalescingKey != coalescingKey ||
+			v.Status != HookScheduleStatusScheduled || v.CurrentAttempt > 0 {
+			continue
+		}
+
+		if res == nil || v.CreatedAt.After(res.CreatedAt) {
+			res = v
+		}
+	}
+
+	if res == nil {
+		return nil, ErrNotFound
+	}
+
+	return copySchedule(res), nil
+}
+
+func (p *InMemoryPersister) FindHookScheduleByPayloadHash(ctx context.Context, hookConfigurationID string, payloadHash string, since time.Time) (*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res *HookSchedule
+	for _, v := range p.schedules {
+		if v.HookConfigurationID != hookConfigurationID ||
+			v.PayloadHash == nil || *v.PayloadHash != payloadHash ||
+			(v.Status != HookScheduleStatusScheduled && v.Status != HookScheduleStatusExecuted) ||
+			v.CreatedAt.Before(since) {
+			continue
+		}
+
+		if res == nil || v.CreatedAt.Before(res.CreatedAt) {
+			res = v
+		}
+	}
+
+	if res == nil {
+		return nil, ErrNotFound
+	}
+
+	return copySchedule(res), nil
+}
+
+func (p *InMemoryPersister) FindExistingHookScheduleIDs(ctx context.Context, ids []string) ([]string, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []string
+	for _, id := range ids {
+		if _, ok := p.schedules[id]; ok {
+			res = append(res, id)
+		}
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindHookSchedulesByGroupID(ctx context.Context, groupID string) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookSchedule
+	for _, v := range p.schedules {
+		if v.GroupID != nil && *v.GroupID == groupID {
+			res = append(res, copySchedule(v))
 		}
 	}
 
@@ -64,27 +355,162 @@ func (p *InMemoryPersister) FindScheduledHookSchedules(ctx context.Context) ([]*
 }
 
 func (p *InMemoryPersister) WriteHookSchedule(ctx context.Context, c *HookSchedule, e ...*HookExecution) error {
+	return p.WriteHookSchedules(ctx, []*HookSchedule{c}, e...)
+}
+
+func (p *InMemoryPersister) WriteHookSchedules(ctx context.Context, c []*HookSchedule, e ...*HookExecution) error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	for _, v := range c {
+		if v.ClaimedBy == nil {
+			continue
+		}
+
+		stored, ok := p.schedules[v.ID]
+		if !ok || stored.ClaimedBy == nil || *stored.ClaimedBy != *v.ClaimedBy {
+			return ErrClaimLost
+		}
+	}
+
+	for _, v := range c {
+		v = copySchedule(v)
+		v.ClaimedBy, v.ClaimedUntil = nil, nil
+		// like SqlPersister, the URL only changes through ReplaceHookSchedulesURL
+		if stored, ok := p.schedules[v.ID]; ok {
+			v.URL = stored.URL
+		}
+		p.schedules[v.ID] = v
+		p.due.set(v)
+	}
+
+	for _, v := range e {
+		p.executions[v.HookScheduleID] = append(p.executions[v.HookScheduleID], v)
+	}
+
+	return nil
+}
+
+func (p *InMemoryPersister) WriteIdempotentHookSchedules(ctx context.Context, c []*HookSchedule) ([]*HookSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	// schedules are staged until the whole write succeeds
+	res := make([]*HookSchedule, len(c))
+	staged := make(map[string]*HookSchedule)
+	var inserted []*HookSchedule
+	for i, v := range c {
+		if v.IdempotencyKey != nil {
+			stored := p.findByIdempotencyKey(v, staged)
+			if stored != nil {
+				if !stored.HasSamePayload(v) {
+					return nil, &IdempotencyConflictError{IdempotencyKey: *v.IdempotencyKey, HookSchedule: copySchedule(stored)}
+				}
+
+				res[i] = copySchedule(stored)
+				res[i].HookConfiguration = v.HookConfiguration
+				continue
+			}
+		}
+
+		if _, ok := p.schedules[v.ID]; ok {
+			return nil, ErrScheduleAlreadyExists
+		}
+		if _, ok := staged[v.ID]; ok {
+			return nil, ErrScheduleAlreadyExists
+		}
+
+		staged[v.ID] = copySchedule(v)
+		inserted = append(inserted, staged[v.ID])
+		res[i] = v
+	}
+
+	for _, v := range inserted {
+		p.schedules[v.ID] = v
+		p.due.set(v)
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) findByIdempotencyKey(v *HookSchedule, staged map[string]*HookSchedule) *HookSchedule {
+	for _, schedules := range []map[string]*HookSchedule{p.schedules, staged} {
+		for _, stored := range schedules {
+			if stored.IdempotencyKey != nil &&
+				*stored.IdempotencyKey == *v.IdempotencyKey &&
+				stored.HookConfigurationID == v.HookConfigurationID {
+				return stored
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *InMemoryPersister) ReplacePendingHookSchedulePayload(ctx context.Context, id string, payload json.RawMessage, payloadHash *string, updatedAt time.Time) (bool, error) {
 	p.l.Lock()
 	defer p.l.Unlock()
 
-	p.schedules[c.ID] = c
+	v, ok := p.schedules[id]
+	if !ok || v.Status != HookScheduleStatusScheduled || v.CurrentAttempt > 0 ||
+		(v.ClaimedUntil != nil && !v.ClaimedUntil.Before(updatedAt)) {
+		return false, nil
+	}
+
+	v.Payload = payload
+	v.PayloadHash = payloadHash
+	v.UpdatedAt = &updatedAt
+
+	return true, nil
+}
+
+func (p *InMemoryPersister) ReplaceHookSchedulesURL(ctx context.Context, ids []string, url string) error {
+	p.l.Lock()
+	defer p.l.Unlock()
 
-	p.executions[c.ID] = append(p.executions[c.ID], e...)
+	for _, id := range ids {
+		if v, ok := p.schedules[id]; ok {
+			v.URL = url
+		}
+	}
 
 	return nil
 }
 
-func (p *InMemoryPersister) FindHookConfiguration(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) (*HookConfiguration, error) {
+func (p *InMemoryPersister) FindActiveHookConfigurations(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) ([]*HookConfiguration, error) {
 	p.l.Lock()
 	defer p.l.Unlock()
 
+	var res []*HookConfiguration
 	for _, v := range p.configurations {
-		if v.HookDefinitionID == hookDefinitionID && v.Tag == tag {
-			return v, nil
+		if v.HookDefinitionID == hookDefinitionID && v.Tag == tag && !v.Disabled {
+			res = append(res, v)
 		}
 	}
 
-	return nil, ErrNotFound
+	if len(res) == 0 {
+		return nil, ErrNotFound
+	}
+
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].CreatedAt.Equal(res[j].CreatedAt) {
+			return res[i].ID < res[j].ID
+		}
+		return res[i].CreatedAt.Before(res[j].CreatedAt)
+	})
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindHookConfigurationByID(ctx context.Context, id string) (*HookConfiguration, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+	c, ok := p.configurations[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return c, nil
 }
 
 func (p *InMemoryPersister) FindHookConfigurationsByTag(ctx context.Context, tag HookConfigurationTag) ([]*HookConfiguration, error) {
@@ -101,6 +527,20 @@ func (p *InMemoryPersister) FindHookConfigurationsByTag(ctx context.Context, tag
 	return res, nil
 }
 
+func (p *InMemoryPersister) FindHookConfigurationsByDefinitionID(ctx context.Context, hookDefinitionID string) ([]*HookConfiguration, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookConfiguration
+	for _, v := range p.configurations {
+		if v.HookDefinitionID == hookDefinitionID {
+			res = append(res, v)
+		}
+	}
+
+	return res, nil
+}
+
 func (p *InMemoryPersister) FindHookConfigurations(ctx context.Context) ([]*HookConfiguration, error) {
 	p.l.Lock()
 	defer p.l.Unlock()
@@ -156,3 +596,191 @@ func (p *InMemoryPersister) WriteHookDefinitions(ctx context.Context, d ...*Hook
 
 	return nil
 }
+
+func (p *InMemoryPersister) FindHookRecurringSchedules(ctx context.Context) ([]*HookRecurringSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookRecurringSchedule
+	for _, v := range p.recurring {
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindDueHookRecurringSchedules(ctx context.Context, now time.Time) ([]*HookRecurringSchedule, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookRecurringSchedule
+	for _, v := range p.recurring {
+		if v.NextRunAt.After(now) {
+			continue
+		}
+
+		// read along with the current configuration, as the SqlPersister does
+		r := *v
+		if configuration, ok := p.configurations[v.HookConfigurationID]; ok {
+			r.HookConfiguration = configuration
+		}
+		res = append(res, &r)
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) WriteHookRecurringSchedule(ctx context.Context, r *HookRecurringSchedule) error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.recurring[r.ID] = r
+
+	return nil
+}
+
+func (p *InMemoryPersister) FindHookSubscriptionsByTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSubscription, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookSubscription
+	for _, v := range p.subscriptions {
+		if v.Tag == tag {
+			res = append(res, v)
+		}
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) FindHookSubscriptions(ctx context.Context) ([]*HookSubscription, error) {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	var res []*HookSubscription
+	for _, v := range p.subscriptions {
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+func (p *InMemoryPersister) WriteHookSubscription(ctx context.Context, s *HookSubscription) error {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	p.subscriptions[s.ID] = s
+
+	return nil
+}
+
+// copySchedule copies schedules in and out of the persister, so callers modifying a schedule
+// do not race with the workers and schedulers reading it, as with a database.
+func copySchedule(s *HookSchedule) *HookSchedule {
+	c := *s
+	return &c
+}
+
+// copyWithConfiguration copies a schedule read for dispatching along with its stored
+// configuration, like SqlPersister loads it. It must be called with the lock held.
+func (p *InMemoryPersister) copyWithConfiguration(s *HookSchedule) *HookSchedule {
+	c := copySchedule(s)
+	if configuration, ok := p.configurations[s.HookConfigurationID]; ok {
+		c.HookConfiguration = configuration
+	}
+	return c
+}
+
+func lastUpdateOf(s *HookSchedule) time.Time {
+	if s.UpdatedAt != nil {
+		return *s.UpdatedAt
+	}
+	return s.CreatedAt
+}
+
+// dueIndex orders the scheduled schedules by priority, next attempt and ID, so due schedules
+// are found without scanning every schedule. Keys are copied, as stored schedules may be
+// modified by callers before being written again.
+type dueIndex struct {
+	// keys of each priority, ordered by next attempt and ID
+	keys       map[int][]dueKey
+	priorities []int // descending
+	indexed    map[string]dueKey
+}
+
+type dueKey struct {
+	priority int
+	at       time.Time
+	id       string
+}
+
+func (k dueKey) less(other dueKey) bool {
+	if !k.at.Equal(other.at) {
+		return k.at.Before(other.at)
+	}
+	return k.id < other.id
+}
+
+func newDueIndex() *dueIndex {
+	return &dueIndex{keys: make(map[int][]dueKey), indexed: make(map[string]dueKey)}
+}
+
+func (i *dueIndex) search(key dueKey) int {
+	keys := i.keys[key.priority]
+	return sort.Search(len(keys), func(j int) bool { return !keys[j].less(key) })
+}
+
+func (i *dueIndex) set(s *HookSchedule) {
+	i.remove(s.ID)
+	if s.Status != HookScheduleStatusScheduled {
+		return
+	}
+
+	key := dueKey{priority: s.Priority, at: s.NextAttempt(), id: s.ID}
+	if _, ok := i.keys[key.priority]; !ok {
+		pos := sort.Search(len(i.priorities), func(j int) bool { return i.priorities[j] <= key.priority })
+		i.priorities = slices.Insert(i.priorities, pos, key.priority)
+	}
+	i.keys[key.priority] = slices.Insert(i.keys[key.priority], i.search(key), key)
+	i.indexed[s.ID] = key
+}
+
+func (i *dueIndex) remove(id string) {
+	key, ok := i.indexed[id]
+	if !ok {
+		return
+	}
+
+	pos := i.search(key)
+	i.keys[key.priority] = slices.Delete(i.keys[key.priority], pos, pos+1)
+	delete(i.indexed, id)
+
+	if len(i.keys[key.priority]) == 0 {
+		delete(i.keys, key.priority)
+		i.priorities = slices.DeleteFunc(i.priorities, func(priority int) bool { return priority == key.priority })
+	}
+}
+
+// due returns the IDs due at now after the given key, if any.
+func (i *dueIndex) due(now time.Time, after *dueKey, limit int) []string {
+	var res []string
+	for _, priority := range i.priorities {
+		if after != nil && priority > after.priority {
+			continue
+		}
+
+		keys, pos := i.keys[priority], 0
+		if after != nil && priority == after.priority {
+			pos = i.search(dueKey{priority: priority, at: after.at, id: after.id + "\x00"})
+		}
+
+		for ; pos < len(keys) && !keys[pos].at.After(now); pos++ {
+			if limit > 0 && len(res) >= limit {
+				return res
+			}
+			res = append(res, keys[pos].id)
+		}
+	}
+
+	return res
+}
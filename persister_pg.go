@@ -3,10 +3,21 @@ package nautilus
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
+// sqlInsertChunkSize bounds multi-row inserts below the postgres limit of bind parameters.
+const sqlInsertChunkSize = 1000
+
+// pqUniqueViolation is the postgres error code of unique constraint violations.
+const pqUniqueViolation = "23505"
+
 type SqlPersister struct {
 	db *sqlx.DB
 }
@@ -92,29 +103,446 @@ func (p *SqlPersister) FindScheduledHookSchedules(ctx context.Context) ([]*HookS
 	return hookSchedules, nil
 }
 
+func (p *SqlPersister) FindDeadLetterHookSchedules(ctx context.Context, filter DeadLetterFilter) ([]*HookSchedule, error) {
+	query := `SELECT s.* FROM hook_schedules s JOIN hook_configurations c ON c.id = s.hook_configuration_id
+		WHERE s.status IN ($1, $2)`
+	args := []any{HookScheduleStatusFailed, HookScheduleStatusQuarantined}
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		query += fmt.Sprintf(" AND "+condition, len(args))
+	}
+
+	if filter.HookDefinitionID != nil {
+		where("c.hook_definition_id = $%d", *filter.HookDefinitionID)
+	}
+	if filter.Tag != nil {
+		where("s.tag = $%d", *filter.Tag)
+	}
+	if filter.FailedAfter != nil {
+		where("COALESCE(s.updated_at, s.created_at) >= $%d", *filter.FailedAfter)
+	}
+	if filter.FailedBefore != nil {
+		where("COALESCE(s.updated_at, s.created_at) < $%d", *filter.FailedBefore)
+	}
+
+	query += " ORDER BY COALESCE(s.updated_at, s.created_at), s.id"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	hookSchedules := []*HookSchedule{}
+	err := p.db.SelectContext(ctx, &hookSchedules, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+func (p *SqlPersister) FindDueHookSchedules(ctx context.Context, now time.Time, after *HookSchedule, limit int) ([]*HookSchedule, error) {
+	hookSchedules := []*HookSchedule{}
+	var err error
+	if after == nil {
+		err = p.db.SelectContext(ctx, &hookSchedules,
+			"SELECT * FROM hook_schedules WHERE status = $1 AND next_attempt_at <= $2 ORDER BY priority DESC, next_attempt_at, id LIMIT $3",
+			HookScheduleStatusScheduled, now, limit)
+	} else {
+		err = p.db.SelectContext(ctx, &hookSchedules,
+			"SELECT * FROM hook_schedules WHERE status = $1 AND next_attempt_at <= $2 AND (priority < $3 OR (priority = $3 AND (next_attempt_at, id) > ($4, $5))) ORDER BY priority DESC, next_attempt_at, id LIMIT $6",
+			HookScheduleStatusScheduled, now, after.Priority, after.NextAttempt(), after.ID, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadHookConfigurations(ctx, hookSchedules)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+// ClaimScheduledHookSchedules leases the schedules with FOR UPDATE SKIP LOCKED, so concurrent
+// claims of other instances skip the rows being claimed instead of waiting for them.
+func (p *SqlPersister) ClaimScheduledHookSchedules(ctx context.Context, claimedBy string, lease time.Duration, limit int) ([]*HookSchedule, error) {
+	now := time.Now().UTC()
+	hookSchedules := []*HookSchedule{}
+	// the rows returned by UPDATE are not ordered, so they are ordered again once claimed
+	err := p.db.SelectContext(ctx, &hookSchedules,
+		`WITH claimed AS (
+			UPDATE hook_schedules SET claimed_by = $1, claimed_until = $2
+			WHERE id IN (
+				SELECT id FROM hook_schedules
+				WHERE status = $3 AND next_attempt_at <= $4 AND (claimed_until IS NULL OR claimed_until < $4)
+				ORDER BY priority DESC, next_attempt_at, id
+				LIMIT $5
+				FOR UPDATE SKIP LOCKED)
+			RETURNING *)
+		SELECT * FROM claimed ORDER BY priority DESC, next_attempt_at, id`,
+		claimedBy, now.Add(lease), HookScheduleStatusScheduled, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadHookConfigurations(ctx, hookSchedules)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+// loadHookConfigurations sets the configurations of the schedules read for dispatching with a
+// single query, so they are routed to their worker pool without a query per schedule.
+func (p *SqlPersister) loadHookConfigurations(ctx context.Context, hookSchedules []*HookSchedule) error {
+	if len(hookSchedules) == 0 {
+		return nil
+	}
+
+	var ids []string
+	seen := make(map[string]bool)
+	for _, schedule := range hookSchedules {
+		if !seen[schedule.HookConfigurationID] {
+			seen[schedule.HookConfigurationID] = true
+			ids = append(ids, schedule.HookConfigurationID)
+		}
+	}
+
+	hookConfigurations := []*HookConfiguration{}
+	err := p.db.SelectContext(ctx, &hookConfigurations, "SELECT * FROM hook_configurations WHERE id = ANY($1)", pq.StringArray(ids))
+	if err != nil {
+		return err
+	}
+
+	byID := make(map[string]*HookConfiguration, len(hookConfigurations))
+	for _, configuration := range hookConfigurations {
+		byID[configuration.ID] = configuration
+	}
+
+	for _, schedule := range hookSchedules {
+		schedule.HookConfiguration = byID[schedule.HookConfigurationID]
+	}
+
+	return nil
+}
+
+func (p *SqlPersister) ClaimHookSchedule(ctx context.Context, id string, claimedBy string, lease time.Duration) (*HookSchedule, error) {
+	now := time.Now().UTC()
+	hookSchedule := &HookSchedule{}
+	err := p.db.GetContext(ctx, hookSchedule,
+		`UPDATE hook_schedules SET claimed_by = $1, claimed_until = $2
+		WHERE id = $3 AND status = $4 AND (claimed_until IS NULL OR claimed_until < $5)
+		RETURNING *`,
+		claimedBy, now.Add(lease), id, HookScheduleStatusScheduled, now)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = p.loadHookConfigurations(ctx, []*HookSchedule{hookSchedule})
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedule, nil
+}
+
+func (p *SqlPersister) ClaimHookSchedules(ctx context.Context, ids []string, claimedBy string, heldBy *string, lease time.Duration) ([]*HookSchedule, error) {
+	now := time.Now().UTC()
+	hookSchedules := []*HookSchedule{}
+	err := p.db.SelectContext(ctx, &hookSchedules,
+		`UPDATE hook_schedules SET claimed_by = $1, claimed_until = $2
+		WHERE id = ANY($3) AND status = $4 AND (claimed_until IS NULL OR claimed_until < $5 OR claimed_by = $6)
+		RETURNING *`,
+		claimedBy, now.Add(lease), pq.StringArray(ids), HookScheduleStatusScheduled, now, heldBy)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+func (p *SqlPersister) ReleaseHookScheduleClaim(ctx context.Context, id string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE hook_schedules SET claimed_by = NULL, claimed_until = NULL WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *SqlPersister) FindHookSchedulePredecessors(ctx context.Context, s *HookSchedule) ([]*HookSchedule, error) {
+	hookSchedules := []*HookSchedule{}
+	if s.OrderingKey == nil {
+		return hookSchedules, nil
+	}
+
+	err := p.db.SelectContext(ctx, &hookSchedules,
+		`SELECT * FROM hook_schedules
+			WHERE hook_configuration_id = $1 AND ordering_key = $2 AND (created_at, id) < ($3, $4) AND status IN ($5, $6, $7)
+			ORDER BY created_at, id`,
+		s.HookConfigurationID, *s.OrderingKey, s.CreatedAt, s.ID,
+		HookScheduleStatusScheduled, HookScheduleStatusFailed, HookScheduleStatusQuarantined)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+func (p *SqlPersister) FindScheduledHookSchedulesOfConfiguration(ctx context.Context, hookConfigurationID string, limit int) ([]*HookSchedule, error) {
+	hookSchedules := []*HookSchedule{}
+	err := p.db.SelectContext(ctx, &hookSchedules,
+		"SELECT * FROM hook_schedules WHERE hook_configuration_id = $1 AND status = $2 ORDER BY created_at, id LIMIT $3",
+		hookConfigurationID, HookScheduleStatusScheduled, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
+func (p *SqlPersister) FindPendingHookScheduleByCoalescingKey(ctx context.Context, hookConfigurationID string, coalescingKey string) (*HookSchedule, error) {
+	hookSchedule := &HookSchedule{}
+	err := p.db.GetContext(ctx, hookSchedule,
+		"SELECT * FROM hook_schedules WHERE hook_configuration_id = $1 AND coalescing_key = $2 AND status = $3 AND current_attempt = 0 ORDER BY created_at DESC LIMIT 1",
+		hookConfigurationID, coalescingKey, HookScheduleStatusScheduled)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedule, nil
+}
+
+func (p *SqlPersister) FindHookScheduleByPayloadHash(ctx context.Context, hookConfigurationID string, payloadHash string, since time.Time) (*HookSchedule, error) {
+	hookSchedule := &HookSchedule{}
+	err := p.db.GetContext(ctx, hookSchedule,
+		"SELECT * FROM hook_schedules WHERE hook_configuration_id = $1 AND payload_hash = $2 AND status IN ($3, $4) AND created_at >= $5 ORDER BY created_at LIMIT 1",
+		hookConfigurationID, payloadHash, HookScheduleStatusScheduled, HookScheduleStatusExecuted, since)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedule, nil
+}
+
+func (p *SqlPersister) FindExistingHookScheduleIDs(ctx context.Context, ids []string) ([]string, error) {
+	existingIDs := []string{}
+	if len(ids) == 0 {
+		return existingIDs, nil
+	}
+
+	err := p.db.SelectContext(ctx, &existingIDs, "SELECT id FROM hook_schedules WHERE id = ANY($1)", pq.StringArray(ids))
+	if err != nil {
+		return nil, err
+	}
+
+	return existingIDs, nil
+}
+
+func (p *SqlPersister) FindHookSchedulesByGroupID(ctx context.Context, groupID string) ([]*HookSchedule, error) {
+	hookSchedules := []*HookSchedule{}
+	err := p.db.SelectContext(ctx, &hookSchedules, "SELECT * FROM hook_schedules WHERE group_id = $1", groupID)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSchedules, nil
+}
+
 func (p *SqlPersister) WriteHookSchedule(ctx context.Context, c *HookSchedule, e ...*HookExecution) error {
+	return p.WriteHookSchedules(ctx, []*HookSchedule{c}, e...)
+}
+
+func (p *SqlPersister) WriteHookSchedules(ctx context.Context, c []*HookSchedule, e ...*HookExecution) error {
 	tx := p.db.MustBeginTx(ctx, nil)
-	_, err := tx.NamedExecContext(ctx,
-		`INSERT INTO hook_schedules (id, hook_configuration_id, http_request_method, url, payload, status, max_attempt, current_attempt, hide_execution_metadata, created_at, updated_at)
-			VALUES 					(:id, :hook_configuration_id, :http_request_method, :url, :payload, :status, :max_attempt, :current_attempt, :hide_execution_metadata, :created_at, :updated_at)
-			ON CONFLICT (id)
-			DO UPDATE SET status = excluded.status , current_attempt = excluded.current_attempt, hide_execution_metadata = excluded.hide_execution_metadata, updated_at = excluded.updated_at;`, c)
+	err := p.writeHookSchedules(ctx, tx, c, e...)
 	if err != nil {
 		tx.Rollback()
 		return err
 	}
 
-	for _, execution := range e {
-		_, err := tx.NamedExecContext(ctx,
-			`INSERT INTO hook_executions (id, hook_schedule_id, response_status, request_payload, response_payload, created_at) 
-			VALUES (:id, :hook_schedule_id, :response_status, :request_payload, :response_payload, :created_at)`, execution)
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// WriteHookSchedulesTx writes the schedules within the caller transaction, so they are
+// only committed alongside the caller data. The transaction is not committed nor rolled back.
+func (p *SqlPersister) WriteHookSchedulesTx(ctx context.Context, tx SqlTx, c []*HookSchedule) error {
+	return p.writeHookSchedules(ctx, tx, c)
+}
+
+// writeHookSchedules writes schedules and executions using multi-row inserts of up to
+// sqlInsertChunkSize rows each. Claimed schedules are updated one by one instead, fenced
+// by their claim.
+func (p *SqlPersister) writeHookSchedules(ctx context.Context, tx SqlTx, c []*HookSchedule, e ...*HookExecution) error {
+	var unclaimed []*HookSchedule
+	for _, schedule := range c {
+		if schedule.ClaimedBy == nil {
+			unclaimed = append(unclaimed, schedule)
+			continue
+		}
+
+		err := p.writeClaimedHookSchedule(ctx, tx, schedule)
+		if err != nil {
+			return err
+		}
+	}
+
+	for chunk := range slices.Chunk(unclaimed, sqlInsertChunkSize) {
+		err := p.namedExecContext(ctx, tx,
+			`INSERT INTO hook_schedules (id, hook_configuration_id, tag, http_request_method, url, payload, status, max_attempt, current_attempt, panic_count, hide_execution_metadata, ordering_key, priority, group_id, idempotency_key, coalescing_key, payload_hash, next_attempt_at, created_at, updated_at)
+			VALUES 					(:id, :hook_configuration_id, :tag, :http_request_method, :url, :payload, :status, :max_attempt, :current_attempt, :panic_count, :hide_execution_metadata, :ordering_key, :priority, :group_id, :idempotency_key, :coalescing_key, :payload_hash, COALESCE(:next_attempt_at, :created_at), :created_at, :updated_at)
+			ON CONFLICT (id)
+			DO UPDATE SET status = excluded.status, current_attempt = excluded.current_attempt, panic_count = excluded.panic_count, hide_execution_metadata = excluded.hide_execution_metadata, next_attempt_at = excluded.next_attempt_at, updated_at = excluded.updated_at,
+				claimed_by = NULL, claimed_until = NULL;`, chunk)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
 
+	for chunk := range slices.Chunk(e, sqlInsertChunkSize) {
+		err := p.namedExecContext(ctx, tx,
+			`INSERT INTO hook_executions (id, hook_schedule_id, response_status, request_payload, response_payload, batch_id, created_at) 
+			VALUES (:id, :hook_schedule_id, :response_status, :request_payload, :response_payload, :batch_id, :created_at)`, chunk)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeClaimedHookSchedule writes the result of an execution as long as the schedule is still
+// claimed by it, releasing the claim.
+func (p *SqlPersister) writeClaimedHookSchedule(ctx context.Context, tx SqlTx, schedule *HookSchedule) error {
+	q, args, err := sqlx.Named(
+		`UPDATE hook_schedules SET status = :status, current_attempt = :current_attempt, panic_count = :panic_count,
+			hide_execution_metadata = :hide_execution_metadata, next_attempt_at = COALESCE(:next_attempt_at, :created_at), updated_at = :updated_at,
+			claimed_by = NULL, claimed_until = NULL
+		WHERE id = :id AND claimed_by = :claimed_by`, schedule)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, p.db.Rebind(q), args...)
+	if err != nil {
+		return err
+	}
+
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return ErrClaimLost
+	}
+
+	return nil
+}
+
+func (p *SqlPersister) WriteIdempotentHookSchedules(ctx context.Context, c []*HookSchedule) ([]*HookSchedule, error) {
+	tx := p.db.MustBeginTx(ctx, nil)
+	res, err := p.writeIdempotentHookSchedules(ctx, tx, c)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// writeIdempotentHookSchedules relies on the unique index of configuration and idempotency key,
+// so concurrent writers of the same key end up with a single schedule.
+func (p *SqlPersister) writeIdempotentHookSchedules(ctx context.Context, tx *sqlx.Tx, c []*HookSchedule) ([]*HookSchedule, error) {
+	res := make([]*HookSchedule, len(c))
+	for i, v := range c {
+		q, args, err := sqlx.Named(
+			`INSERT INTO hook_schedules (id, hook_configuration_id, tag, http_request_method, url, payload, status, max_attempt, current_attempt, panic_count, hide_execution_metadata, ordering_key, priority, group_id, idempotency_key, coalescing_key, payload_hash, next_attempt_at, created_at, updated_at)
+			VALUES 					(:id, :hook_configuration_id, :tag, :http_request_method, :url, :payload, :status, :max_attempt, :current_attempt, :panic_count, :hide_execution_metadata, :ordering_key, :priority, :group_id, :idempotency_key, :coalescing_key, :payload_hash, COALESCE(:next_attempt_at, :created_at), :created_at, :updated_at)
+			ON CONFLICT (hook_configuration_id, idempotency_key) WHERE idempotency_key IS NOT NULL
+			DO NOTHING
+			RETURNING id;`, v)
+		if err != nil {
+			return nil, err
+		}
+
+		var id string
+		err = tx.QueryRowxContext(ctx, p.db.Rebind(q), args...).Scan(&id)
+		if err == nil {
+			res[i] = v
+			continue
+		}
+
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == pqUniqueViolation && pqErr.Constraint == "hook_schedules_pkey" {
+			return nil, ErrScheduleAlreadyExists
+		}
+
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+
+		stored := &HookSchedule{}
+		err = tx.GetContext(ctx, stored,
+			"SELECT * FROM hook_schedules WHERE hook_configuration_id = $1 AND idempotency_key = $2",
+			v.HookConfigurationID, v.IdempotencyKey)
+		if err != nil {
+			return nil, err
+		}
+
+		stored.HookConfiguration = v.HookConfiguration
+		if !stored.HasSamePayload(v) {
+			return nil, &IdempotencyConflictError{IdempotencyKey: *v.IdempotencyKey, HookSchedule: stored}
+		}
+
+		res[i] = stored
+	}
+
+	return res, nil
+}
+
+func (p *SqlPersister) ReplacePendingHookSchedulePayload(ctx context.Context, id string, payload json.RawMessage, payloadHash *string, updatedAt time.Time) (bool, error) {
+	res, err := p.db.ExecContext(ctx,
+		`UPDATE hook_schedules SET payload = $1, payload_hash = $2, updated_at = $3
+		WHERE id = $4 AND status = $5 AND current_attempt = 0 AND (claimed_until IS NULL OR claimed_until < $3)`,
+		payload, payloadHash, updatedAt, id, HookScheduleStatusScheduled)
+	if err != nil {
+		return false, err
+	}
+
+	rows, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rows > 0, nil
+}
+
+func (p *SqlPersister) ReplaceHookSchedulesURL(ctx context.Context, ids []string, url string) error {
+	_, err := p.db.ExecContext(ctx, "UPDATE hook_schedules SET url = $1 WHERE id = ANY($2)", url, pq.StringArray(ids))
 	if err != nil {
 		return err
 	}
@@ -122,30 +550,53 @@ func (p *SqlPersister) WriteHookSchedule(ctx context.Context, c *HookSchedule, e
 	return nil
 }
 
-func (p *SqlPersister) FindHookConfiguration(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) (*HookConfiguration, error) {
-	hookConfiguration := &HookConfiguration{}
-	err := p.db.GetContext(ctx, hookConfiguration, "SELECT * FROM hook_configurations WHERE hook_definition_id = $1 AND tag = $2", hookDefinitionID, tag)
-	if err == sql.ErrNoRows {
-		return nil, ErrNotFound
+// namedExecContext binds named queries for any SqlTx, as *sql.Tx has no support for them.
+func (p *SqlPersister) namedExecContext(ctx context.Context, tx SqlTx, query string, arg any) error {
+	q, args, err := sqlx.Named(query, arg)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, p.db.Rebind(q), args...)
+	if err != nil {
+		return err
 	}
 
+	return nil
+}
+
+func (p *SqlPersister) FindActiveHookConfigurations(ctx context.Context, hookDefinitionID string, tag HookConfigurationTag) ([]*HookConfiguration, error) {
+	hookConfigurations := []*HookConfiguration{}
+	err := p.db.SelectContext(ctx, &hookConfigurations,
+		"SELECT * FROM hook_configurations WHERE hook_definition_id = $1 AND tag = $2 AND NOT disabled ORDER BY created_at, id",
+		hookDefinitionID, tag)
 	if err != nil {
 		return nil, err
 	}
 
-	hookDefinition, err := p.FindHookDefinitionByID(ctx, hookConfiguration.HookDefinitionID)
+	if len(hookConfigurations) == 0 {
+		return nil, ErrNotFound
+	}
+
+	hookDefinition, err := p.FindHookDefinitionByID(ctx, hookDefinitionID)
 	if err != nil {
 		return nil, err
 	}
 
-	hookConfiguration.HookDefinition = hookDefinition
+	for _, hookConfiguration := range hookConfigurations {
+		hookConfiguration.HookDefinition = hookDefinition
+	}
 
-	return hookConfiguration, nil
+	return hookConfigurations, nil
 }
 
 func (p *SqlPersister) FindHookConfigurationByID(ctx context.Context, id string) (*HookConfiguration, error) {
 	hookConfiguration := &HookConfiguration{}
 	err := p.db.GetContext(ctx, hookConfiguration, "SELECT * FROM hook_configurations WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -170,6 +621,16 @@ func (p *SqlPersister) FindHookConfigurationsByTag(ctx context.Context, tag Hook
 	return hookConfigurations, nil
 }
 
+func (p *SqlPersister) FindHookConfigurationsByDefinitionID(ctx context.Context, hookDefinitionID string) ([]*HookConfiguration, error) {
+	hookConfigurations := []*HookConfiguration{}
+	err := p.db.SelectContext(ctx, &hookConfigurations, "SELECT * FROM hook_configurations WHERE hook_definition_id = $1", hookDefinitionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookConfigurations, nil
+}
+
 func (p *SqlPersister) FindHookConfigurations(ctx context.Context) ([]*HookConfiguration, error) {
 	hookConfigurations := []*HookConfiguration{}
 	err := p.db.SelectContext(ctx, &hookConfigurations, "SELECT * FROM hook_configurations")
@@ -182,10 +643,11 @@ func (p *SqlPersister) FindHookConfigurations(ctx context.Context) ([]*HookConfi
 
 func (p *SqlPersister) WriteHookConfiguration(ctx context.Context, c *HookConfiguration) error {
 	_, err := p.db.NamedExecContext(ctx,
-		`INSERT INTO hook_configurations (id, hook_definition_id, url, tag, client_secret, client_rsa_private_key, created_at)
-			VALUES (:id, :hook_definition_id, :url, :tag, :client_secret, :client_rsa_private_key, :created_at)
+		`INSERT INTO hook_configurations (id, hook_definition_id, url, tag, client_secret, client_rsa_private_key, disabled, filter, subscription_id, created_at)
+			VALUES (:id, :hook_definition_id, :url, :tag, :client_secret, :client_rsa_private_key, :disabled, :filter, :subscription_id, :created_at)
 			ON CONFLICT (id) 
-			DO UPDATE SET url = excluded.url, tag = excluded.tag, client_secret = excluded.client_secret, client_rsa_private_key = excluded.client_rsa_private_key, created_at = excluded.created_at;`, c)
+			DO UPDATE SET url = excluded.url, tag = excluded.tag, client_secret = excluded.client_secret, client_rsa_private_key = excluded.client_rsa_private_key, disabled = excluded.disabled, filter = excluded.filter,
+				created_at = excluded.created_at;`, c)
 	if err != nil {
 		return err
 	}
@@ -196,6 +658,10 @@ func (p *SqlPersister) WriteHookConfiguration(ctx context.Context, c *HookConfig
 func (p *SqlPersister) FindHookDefinitionByID(ctx context.Context, id string) (*HookDefinition, error) {
 	hookDefinition := &HookDefinition{}
 	err := p.db.GetContext(ctx, hookDefinition, "SELECT * FROM hook_definitions WHERE id = $1", id)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -217,11 +683,13 @@ func (p *SqlPersister) WriteHookDefinitions(ctx context.Context, d ...*HookDefin
 	tx := p.db.MustBeginTx(ctx, nil)
 	for _, definition := range d {
 		_, err := tx.NamedExecContext(ctx,
-			`INSERT INTO hook_definitions (id, name, description, payload_scheme, http_request_method, total_attempts)
-				VALUES (:id, :name, :description, :payload_scheme, :http_request_method, :total_attempts)
+			`INSERT INTO hook_definitions (id, name, description, payload_scheme, http_request_method, total_attempts, ordering_failure_policy, priority, batch_max_size, batch_max_wait, debounce_window, debounce_max_wait, deduplication_window)
+				VALUES (:id, :name, :description, :payload_scheme, :http_request_method, :total_attempts, :ordering_failure_policy, :priority, :batch_max_size, :batch_max_wait, :debounce_window, :debounce_max_wait, :deduplication_window)
 				ON CONFLICT (id) 
 				DO UPDATE SET name = excluded.name, description = excluded.description, payload_scheme = excluded.payload_scheme, http_request_method = excluded.http_request_method,
-					total_attempts = excluded.total_attempts;`, definition)
+					total_attempts = excluded.total_attempts, ordering_failure_policy = excluded.ordering_failure_policy,
+					priority = excluded.priority, batch_max_size = excluded.batch_max_size, batch_max_wait = excluded.batch_max_wait,
+					debounce_window = excluded.debounce_window, debounce_max_wait = excluded.debounce_max_wait, deduplication_window = excluded.deduplication_window;`, definition)
 		if err != nil {
 			tx.Rollback()
 			return err
@@ -235,3 +703,79 @@ func (p *SqlPersister) WriteHookDefinitions(ctx context.Context, d ...*HookDefin
 
 	return nil
 }
+
+func (p *SqlPersister) FindHookRecurringSchedules(ctx context.Context) ([]*HookRecurringSchedule, error) {
+	recurringSchedules := []*HookRecurringSchedule{}
+	err := p.db.SelectContext(ctx, &recurringSchedules, "SELECT * FROM hook_recurring_schedules")
+	if err != nil {
+		return nil, err
+	}
+
+	return recurringSchedules, nil
+}
+
+func (p *SqlPersister) FindDueHookRecurringSchedules(ctx context.Context, now time.Time) ([]*HookRecurringSchedule, error) {
+	recurringSchedules := []*HookRecurringSchedule{}
+	err := p.db.SelectContext(ctx, &recurringSchedules, "SELECT * FROM hook_recurring_schedules WHERE next_run_at <= $1", now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, recurringSchedule := range recurringSchedules {
+		hookConfiguration, err := p.FindHookConfigurationByID(ctx, recurringSchedule.HookConfigurationID)
+		if err != nil {
+			return nil, err
+		}
+		recurringSchedule.HookConfiguration = hookConfiguration
+	}
+
+	return recurringSchedules, nil
+}
+
+func (p *SqlPersister) WriteHookRecurringSchedule(ctx context.Context, r *HookRecurringSchedule) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO hook_recurring_schedules (id, hook_configuration_id, cron_expression, time_zone, payload, payload_generator, next_run_at, last_run_at, created_at)
+			VALUES (:id, :hook_configuration_id, :cron_expression, :time_zone, :payload, :payload_generator, :next_run_at, :last_run_at, :created_at)
+			ON CONFLICT (id)
+			DO UPDATE SET cron_expression = excluded.cron_expression, time_zone = excluded.time_zone, payload = excluded.payload, payload_generator = excluded.payload_generator,
+				next_run_at = excluded.next_run_at, last_run_at = excluded.last_run_at;`, r)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (p *SqlPersister) FindHookSubscriptionsByTag(ctx context.Context, tag HookConfigurationTag) ([]*HookSubscription, error) {
+	hookSubscriptions := []*HookSubscription{}
+	err := p.db.SelectContext(ctx, &hookSubscriptions, "SELECT * FROM hook_subscriptions WHERE tag = $1", tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSubscriptions, nil
+}
+
+func (p *SqlPersister) FindHookSubscriptions(ctx context.Context) ([]*HookSubscription, error) {
+	hookSubscriptions := []*HookSubscription{}
+	err := p.db.SelectContext(ctx, &hookSubscriptions, "SELECT * FROM hook_subscriptions")
+	if err != nil {
+		return nil, err
+	}
+
+	return hookSubscriptions, nil
+}
+
+func (p *SqlPersister) WriteHookSubscription(ctx context.Context, s *HookSubscription) error {
+	_, err := p.db.NamedExecContext(ctx,
+		`INSERT INTO hook_subscriptions (id, tag, url, hook_definition_patterns, client_secret, client_rsa_private_key, filter, created_at)
+			VALUES (:id, :tag, :url, :hook_definition_patterns, :client_secret, :client_rsa_private_key, :filter, :created_at)
+			ON CONFLICT (id)
+			DO UPDATE SET tag = excluded.tag, url = excluded.url, hook_definition_patterns = excluded.hook_definition_patterns, client_secret = excluded.client_secret,
+				client_rsa_private_key = excluded.client_rsa_private_key, filter = excluded.filter;`, s)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
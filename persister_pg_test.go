@@ -2,7 +2,6 @@ package nautilus
 
 import (
 	"context"
-	"database/sql"
 	"encoding/json"
 	"net/http"
 	"testing"
@@ -11,6 +10,7 @@ import (
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/devmalloni/nautilus/x"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
 func mustCreateTestPersister(t *testing.T) (*SqlPersister, sqlmock.Sqlmock, func() error) {
@@ -275,6 +275,80 @@ func TestSqlPersister_FindScheduledHookSchedules(t *testing.T) {
 	}
 }
 
+func TestSqlPersister_FindDueHookSchedules(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	after := &HookSchedule{
+		ID:            "schedule-id-1",
+		Priority:      5,
+		CreatedAt:     now,
+		NextAttemptAt: &now,
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM hook_schedules WHERE status = (.+) AND next_attempt_at <= (.+) AND \(priority < (.+) OR \(priority = (.+) AND \(next_attempt_at, id\) > (.+)\)\) ORDER BY priority DESC, next_attempt_at, id LIMIT`).
+		WithArgs(HookScheduleStatusScheduled, now, 5, now, after.ID, 2).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id",
+			"hook_configuration_id",
+			"status",
+			"next_attempt_at",
+			"created_at",
+		}).AddRow(
+			"schedule-id-2",
+			"hook-config-id",
+			HookScheduleStatusScheduled,
+			now,
+			now,
+		))
+
+	mock.ExpectQuery(`SELECT \* FROM hook_configurations WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.StringArray{"hook-config-id"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "tag"}).AddRow("hook-config-id", "tenant-1"))
+
+	res, err := persister.FindDueHookSchedules(context.Background(), now, after, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(res))
+	}
+	if res[0].HookConfiguration == nil || res[0].HookConfiguration.Tag != "tenant-1" {
+		t.Fatalf("expected schedule read with its configuration, got %+v", res[0].HookConfiguration)
+	}
+}
+
+func TestSqlPersister_FindDeadLetterHookSchedules(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	tag := HookConfigurationTag("tenant-1")
+
+	mock.ExpectQuery(`SELECT s.\* FROM hook_schedules s JOIN hook_configurations c (.+) WHERE s.status IN \(\$1, \$2\) AND s.tag = \$3 AND (.+) >= \$4 ORDER BY (.+) LIMIT \$5`).
+		WithArgs(HookScheduleStatusFailed, HookScheduleStatusQuarantined, tag, now, 10).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id",
+			"hook_configuration_id",
+			"status",
+			"created_at",
+		}).AddRow(
+			"schedule-id",
+			"hook-config-id",
+			HookScheduleStatusFailed,
+			now,
+		))
+
+	res, err := persister.FindDeadLetterHookSchedules(context.Background(), DeadLetterFilter{Tag: &tag, FailedAfter: &now, Limit: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(res))
+	}
+}
+
 func TestSqlPersister_WriteHookSchedule(t *testing.T) {
 	persister, mock, close := mustCreateTestPersister(t)
 	defer close()
@@ -307,13 +381,23 @@ func TestSqlPersister_WriteHookSchedule(t *testing.T) {
 	mock.ExpectExec(`INSERT INTO hook_schedules`).
 		WithArgs(schedule.ID,
 			schedule.HookConfigurationID,
+			schedule.Tag,
 			schedule.HttpRequestMethod,
 			schedule.URL,
 			schedule.Payload,
 			schedule.Status,
 			schedule.MaxAttempt,
 			schedule.CurrentAttempt,
+			schedule.PanicCount,
 			schedule.HideExecutionMetadata,
+			schedule.OrderingKey,
+			schedule.Priority,
+			schedule.GroupID,
+			schedule.IdempotencyKey,
+			schedule.CoalescingKey,
+			schedule.PayloadHash,
+			schedule.NextAttemptAt,
+			schedule.CreatedAt,
 			schedule.CreatedAt,
 			schedule.UpdatedAt).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -325,6 +409,7 @@ func TestSqlPersister_WriteHookSchedule(t *testing.T) {
 			execution.ResponseStatus,
 			execution.RequestPayload,
 			execution.ResponsePayload,
+			execution.BatchID,
 			execution.CreatedAt,
 		).
 		WillReturnResult(sqlmock.NewResult(1, 1))
@@ -337,7 +422,7 @@ func TestSqlPersister_WriteHookSchedule(t *testing.T) {
 	}
 }
 
-func TestSqlPersister_FindHookConfiguration(t *testing.T) {
+func TestSqlPersister_FindActiveHookConfigurations(t *testing.T) {
 	persister, mock, close := mustCreateTestPersister(t)
 	defer close()
 
@@ -352,7 +437,7 @@ func TestSqlPersister_FindHookConfiguration(t *testing.T) {
 	}
 
 	mock.ExpectQuery(`SELECT (.+) FROM hook_configurations`).
-		WithArgs(expectedConfiguration.ID, expectedConfiguration.Tag).
+		WithArgs(expectedConfiguration.HookDefinitionID, expectedConfiguration.Tag).
 		WillReturnRows(sqlmock.NewRows([]string{
 			"id",
 			"hook_definition_id",
@@ -397,7 +482,7 @@ func TestSqlPersister_FindHookConfiguration(t *testing.T) {
 			expectedDefinition.TotalAttempts,
 		))
 
-	config, err := persister.FindHookConfiguration(context.Background(), expectedConfiguration.ID, HookConfigurationTag(expectedConfiguration.Tag))
+	config, err := persister.FindActiveHookConfigurations(context.Background(), expectedConfiguration.HookDefinitionID, HookConfigurationTag(expectedConfiguration.Tag))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -565,15 +650,15 @@ func TestSqlPersister_FindHookConfigurations(t *testing.T) {
 	}
 }
 
-func TestSqlPersister_FindHookConfiguration_NoRows(t *testing.T) {
+func TestSqlPersister_FindActiveHookConfigurations_NoRows(t *testing.T) {
 	persister, mock, close := mustCreateTestPersister(t)
 	defer close()
 
 	mock.ExpectQuery(`SELECT (.+) FROM hook_configurations`).
 		WithArgs("foo", "tag").
-		WillReturnError(sql.ErrNoRows)
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
 
-	_, err := persister.FindHookConfiguration(context.Background(), "foo", HookConfigurationTag("tag"))
+	_, err := persister.FindActiveHookConfigurations(context.Background(), "foo", HookConfigurationTag("tag"))
 	if err != ErrNotFound {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -602,6 +687,9 @@ func TestSqlPersister_WriteHookConfiguration(t *testing.T) {
 			configuration.Tag,
 			configuration.ClientSecret,
 			configuration.ClientRSAPrivateKey,
+			configuration.Disabled,
+			configuration.Filter,
+			configuration.SubscriptionID,
 			configuration.CreatedAt).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
@@ -716,7 +804,14 @@ func TestSqlPersister_WriteHookDefinitions(t *testing.T) {
 			firstDefinition.Description,
 			firstDefinition.PayloadScheme,
 			firstDefinition.HttpRequestMethod,
-			firstDefinition.TotalAttempts).
+			firstDefinition.TotalAttempts,
+			firstDefinition.OrderingFailurePolicy,
+			firstDefinition.Priority,
+			firstDefinition.BatchMaxSize,
+			firstDefinition.BatchMaxWait,
+			firstDefinition.DebounceWindow,
+			firstDefinition.DebounceMaxWait,
+			firstDefinition.DeduplicationWindow).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	mock.ExpectExec(`INSERT INTO hook_definitions`).
@@ -726,7 +821,14 @@ func TestSqlPersister_WriteHookDefinitions(t *testing.T) {
 			secondDefinition.Description,
 			secondDefinition.PayloadScheme,
 			secondDefinition.HttpRequestMethod,
-			secondDefinition.TotalAttempts).
+			secondDefinition.TotalAttempts,
+			secondDefinition.OrderingFailurePolicy,
+			secondDefinition.Priority,
+			secondDefinition.BatchMaxSize,
+			secondDefinition.BatchMaxWait,
+			secondDefinition.DebounceWindow,
+			secondDefinition.DebounceMaxWait,
+			secondDefinition.DeduplicationWindow).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 	mock.ExpectCommit()
 
@@ -735,3 +837,346 @@ func TestSqlPersister_WriteHookDefinitions(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 }
+
+func TestSqlPersister_FindHookSchedulePredecessors(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	schedule := &HookSchedule{
+		ID:                  "schedule-id",
+		HookConfigurationID: "hook-config-id",
+		OrderingKey:         x.NullString("entity-id"),
+		CreatedAt:           now,
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM hook_schedules`).
+		WithArgs(schedule.HookConfigurationID,
+			*schedule.OrderingKey,
+			schedule.CreatedAt,
+			schedule.ID,
+			HookScheduleStatusScheduled,
+			HookScheduleStatusFailed,
+			HookScheduleStatusQuarantined).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id",
+			"hook_configuration_id",
+			"status",
+			"ordering_key",
+			"created_at",
+		}).AddRow(
+			"previous-schedule-id",
+			schedule.HookConfigurationID,
+			HookScheduleStatusScheduled,
+			*schedule.OrderingKey,
+			now.Add(-time.Second),
+		))
+
+	res, err := persister.FindHookSchedulePredecessors(context.Background(), schedule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(res))
+	}
+}
+
+func TestSqlPersister_FindHookSchedulePredecessors_Quarantined(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	schedule := &HookSchedule{
+		ID:                  "schedule-id",
+		HookConfigurationID: "hook-config-id",
+		OrderingKey:         x.NullString("entity-id"),
+		CreatedAt:           now,
+	}
+
+	mock.ExpectQuery(`SELECT (.+) FROM hook_schedules WHERE (.+) AND status IN \(\$5, \$6, \$7\)`).
+		WithArgs(schedule.HookConfigurationID,
+			*schedule.OrderingKey,
+			schedule.CreatedAt,
+			schedule.ID,
+			HookScheduleStatusScheduled,
+			HookScheduleStatusFailed,
+			HookScheduleStatusQuarantined).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id",
+			"hook_configuration_id",
+			"status",
+			"ordering_key",
+			"created_at",
+		}).AddRow(
+			"quarantined-schedule-id",
+			schedule.HookConfigurationID,
+			HookScheduleStatusQuarantined,
+			*schedule.OrderingKey,
+			now.Add(-time.Second),
+		))
+
+	res, err := persister.FindHookSchedulePredecessors(context.Background(), schedule)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res) != 1 || !schedule.IsBlockedBy(res[0], OrderingFailurePolicyBlock) {
+		t.Fatalf("expected the quarantined predecessor to block the schedule, got %+v", res)
+	}
+}
+
+func TestSqlPersister_WriteHookSchedulesTx(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	schedule := &HookSchedule{
+		ID:                  "schedule-id",
+		HookConfigurationID: "hook-config-id",
+		HttpRequestMethod:   POST,
+		URL:                 "http://example.com",
+		Payload:             json.RawMessage(`{"key":"value"}`),
+		Status:              HookScheduleStatusScheduled,
+		MaxAttempt:          3,
+		CreatedAt:           now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`INSERT INTO orders`).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO hook_schedules`).
+		WithArgs(schedule.ID,
+			schedule.HookConfigurationID,
+			schedule.Tag,
+			schedule.HttpRequestMethod,
+			schedule.URL,
+			schedule.Payload,
+			schedule.Status,
+			schedule.MaxAttempt,
+			schedule.CurrentAttempt,
+			schedule.PanicCount,
+			schedule.HideExecutionMetadata,
+			schedule.OrderingKey,
+			schedule.Priority,
+			schedule.GroupID,
+			schedule.IdempotencyKey,
+			schedule.CoalescingKey,
+			schedule.PayloadHash,
+			schedule.NextAttemptAt,
+			schedule.CreatedAt,
+			schedule.CreatedAt,
+			schedule.UpdatedAt).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	// caller transaction from database/sql
+	tx, err := persister.db.DB.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	_, err = tx.ExecContext(context.Background(), "INSERT INTO orders (id) VALUES ($1)", "order-id")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = persister.WriteHookSchedulesTx(context.Background(), tx, []*HookSchedule{schedule})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_WriteIdempotentHookSchedules(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	schedule := &HookSchedule{
+		ID:                  "retried-schedule-id",
+		HookConfigurationID: "hook-config-id",
+		HttpRequestMethod:   POST,
+		URL:                 "http://example.com",
+		Payload:             json.RawMessage(`{"key":"value","other":1}`),
+		Status:              HookScheduleStatusScheduled,
+		MaxAttempt:          3,
+		IdempotencyKey:      x.NullString("order-123"),
+		CreatedAt:           now,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO hook_schedules .* ON CONFLICT \(hook_configuration_id, idempotency_key\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM hook_schedules WHERE hook_configuration_id = \$1 AND idempotency_key = \$2`).
+		WithArgs(schedule.HookConfigurationID, schedule.IdempotencyKey).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hook_configuration_id", "payload", "idempotency_key"}).
+			AddRow("original-schedule-id", schedule.HookConfigurationID, []byte(`{"other": 1, "key": "value"}`), "order-123"))
+	mock.ExpectCommit()
+
+	res, err := persister.WriteIdempotentHookSchedules(context.Background(), []*HookSchedule{schedule})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res) != 1 || res[0].ID != "original-schedule-id" {
+		t.Fatalf("expected original schedule, got %+v", res)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO hook_schedules`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectQuery(`SELECT \* FROM hook_schedules`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hook_configuration_id", "payload", "idempotency_key"}).
+			AddRow("original-schedule-id", schedule.HookConfigurationID, []byte(`{"key": "changed"}`), "order-123"))
+	mock.ExpectRollback()
+
+	_, err = persister.WriteIdempotentHookSchedules(context.Background(), []*HookSchedule{schedule})
+	conflict, ok := err.(*IdempotencyConflictError)
+	if !ok {
+		t.Fatalf("expected idempotency conflict error, got %v", err)
+	}
+
+	if conflict.HookSchedule.ID != "original-schedule-id" {
+		t.Errorf("expected conflicting schedule to be the original one, got %s", conflict.HookSchedule.ID)
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`INSERT INTO hook_schedules`).
+		WillReturnError(&pq.Error{Code: pqUniqueViolation, Constraint: "hook_schedules_pkey"})
+	mock.ExpectRollback()
+
+	_, err = persister.WriteIdempotentHookSchedules(context.Background(), []*HookSchedule{schedule})
+	if err != ErrScheduleAlreadyExists {
+		t.Errorf("expected ErrScheduleAlreadyExists, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_ClaimScheduledHookSchedules(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	mock.ExpectQuery(`WITH claimed AS \( UPDATE hook_schedules SET claimed_by = \$1, claimed_until = \$2 WHERE id IN \( SELECT id FROM hook_schedules .* ORDER BY priority DESC, next_attempt_at, id .* FOR UPDATE SKIP LOCKED\) RETURNING \*\) SELECT \* FROM claimed ORDER BY priority DESC, next_attempt_at, id`).
+		WithArgs("instance-1", sqlmock.AnyArg(), HookScheduleStatusScheduled, sqlmock.AnyArg(), 10).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "hook_configuration_id", "status", "claimed_by"}).
+			AddRow("schedule-id", "hook-config-id", HookScheduleStatusScheduled, "instance-1"))
+
+	mock.ExpectQuery(`SELECT \* FROM hook_configurations WHERE id = ANY\(\$1\)`).
+		WithArgs(pq.StringArray{"hook-config-id"}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("hook-config-id"))
+
+	res, err := persister.ClaimScheduledHookSchedules(context.Background(), "instance-1", time.Minute, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res) != 1 || res[0].ClaimedBy == nil || *res[0].ClaimedBy != "instance-1" {
+		t.Fatalf("expected schedule claimed by instance-1, got %+v", res)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_ClaimHookSchedules(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	heldBy := "instance-1"
+
+	mock.ExpectQuery(`UPDATE hook_schedules SET claimed_by = \$1, claimed_until = \$2 WHERE id = ANY\(\$3\) AND status = \$4 AND \(claimed_until IS NULL OR claimed_until < \$5 OR claimed_by = \$6\) RETURNING \*`).
+		WithArgs("batch-1", sqlmock.AnyArg(), sqlmock.AnyArg(), HookScheduleStatusScheduled, sqlmock.AnyArg(), &heldBy).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "claimed_by"}).
+			AddRow("schedule-id", HookScheduleStatusScheduled, "batch-1"))
+
+	res, err := persister.ClaimHookSchedules(context.Background(), []string{"schedule-id", "claimed-id"}, "batch-1", &heldBy, time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(res) != 1 || res[0].ClaimedBy == nil || *res[0].ClaimedBy != "batch-1" {
+		t.Fatalf("expected schedule claimed by batch-1, got %+v", res)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_WriteClaimedHookSchedule(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	claimedBy := "execution-1"
+	schedule := &HookSchedule{
+		ID:        "schedule-id",
+		Status:    HookScheduleStatusExecuted,
+		ClaimedBy: &claimedBy,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE hook_schedules SET status = \$1, .* WHERE id = \$8 AND claimed_by = \$9`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	err := persister.WriteHookSchedule(context.Background(), schedule)
+	if err != ErrClaimLost {
+		t.Fatalf("expected claim lost error, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_ReplaceHookSchedulesURL(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	mock.ExpectExec(`UPDATE hook_schedules SET url = \$1 WHERE id = ANY\(\$2\)`).
+		WithArgs("http://crm/v2/webhook", pq.StringArray{"schedule-id"}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := persister.ReplaceHookSchedulesURL(context.Background(), []string{"schedule-id"}, "http://crm/v2/webhook")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatalf("unmet expectations: %v", err)
+	}
+}
+
+func TestSqlPersister_ReplacePendingHookSchedulePayload(t *testing.T) {
+	persister, mock, close := mustCreateTestPersister(t)
+	defer close()
+
+	now := time.Now().UTC()
+	payload := json.RawMessage(`{"name":"b"}`)
+	payloadHash := "hash-b"
+
+	mock.ExpectExec(`UPDATE hook_schedules SET payload = \$1, payload_hash = \$2, updated_at = \$3 WHERE id = \$4`).
+		WithArgs(payload, &payloadHash, now, "schedule-id", HookScheduleStatusScheduled).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	replaced, err := persister.ReplacePendingHookSchedulePayload(context.Background(), "schedule-id", payload, &payloadHash, now)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !replaced {
+		t.Fatal("expected payload to be replaced")
+	}
+}
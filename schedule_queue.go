@@ -0,0 +1,229 @@
+package nautilus
+
+import (
+	"container/heap"
+	"sync"
+)
+
+// scheduleQueue is a bounded priority queue of schedules. Schedules with higher
+// priority are popped first, and schedules with the same priority in push order.
+//
+// When tenantOf is given, the schedules of each tenant are queued apart and the tenants
+// are served in turns, so the schedules of a tenant wait for at most one schedule of each
+// other tenant. Priority then only orders the schedules of the same tenant.
+type scheduleQueue struct {
+	l        *sync.Mutex
+	notEmpty *sync.Cond
+	notFull  *sync.Cond
+	tenantOf func(*HookSchedule) string
+	tenants  map[string]*scheduleHeap
+	turns    []string
+	size     int
+	capacity int
+	seq      uint64
+	closed   bool
+}
+
+type scheduleQueueItem struct {
+	schedule *HookSchedule
+	seq      uint64
+}
+
+type scheduleHeap []scheduleQueueItem
+
+func (h scheduleHeap) Len() int { return len(h) }
+
+func (h scheduleHeap) Less(i, j int) bool {
+	if h[i].schedule.Priority != h[j].schedule.Priority {
+		return h[i].schedule.Priority > h[j].schedule.Priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h scheduleHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *scheduleHeap) Push(x any) { *h = append(*h, x.(scheduleQueueItem)) }
+
+func (h *scheduleHeap) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
+
+func newScheduleQueue(capacity int) *scheduleQueue {
+	return newFairScheduleQueue(capacity, nil)
+}
+
+func newFairScheduleQueue(capacity int, tenantOf func(*HookSchedule) string) *scheduleQueue {
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	l := &sync.Mutex{}
+	return &scheduleQueue{
+		l:        l,
+		notEmpty: sync.NewCond(l),
+		notFull:  sync.NewCond(l),
+		tenantOf: tenantOf,
+		tenants:  make(map[string]*scheduleHeap),
+		capacity: capacity,
+	}
+}
+
+// Push blocks while the queue is full, and reports whether the schedule was pushed.
+// Schedules pushed after Close are dropped.
+func (q *scheduleQueue) Push(s *HookSchedule) bool {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	for q.size >= q.capacity && !q.closed {
+		q.notFull.Wait()
+	}
+
+	if q.closed {
+		return false
+	}
+
+	q.push(s)
+	return true
+}
+
+// TryPush pushes the schedule unless the queue is full or closed, reporting whether it did.
+func (q *scheduleQueue) TryPush(s *HookSchedule) bool {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if q.size >= q.capacity || q.closed {
+		return false
+	}
+
+	q.push(s)
+	return true
+}
+
+func (q *scheduleQueue) push(s *HookSchedule) {
+	tenant := ""
+	if q.tenantOf != nil {
+		tenant = q.tenantOf(s)
+	}
+
+	items, ok := q.tenants[tenant]
+	if !ok {
+		items = &scheduleHeap{}
+		q.tenants[tenant] = items
+		q.turns = append(q.turns, tenant)
+	}
+
+	q.seq++
+	heap.Push(items, scheduleQueueItem{schedule: s, seq: q.seq})
+	q.size++
+	q.notEmpty.Signal()
+}
+
+// pop pops the next schedule of the tenant whose turn it is, and passes the turn on.
+func (q *scheduleQueue) pop() *HookSchedule {
+	tenant := q.turns[0]
+	items := q.tenants[tenant]
+	item := heap.Pop(items).(scheduleQueueItem)
+	q.size--
+
+	q.turns = q.turns[1:]
+	if items.Len() > 0 {
+		q.turns = append(q.turns, tenant)
+	} else {
+		delete(q.tenants, tenant)
+	}
+
+	return item.schedule
+}
+
+// Pop blocks until a schedule is available. It returns false once the queue is closed.
+func (q *scheduleQueue) Pop() (*HookSchedule, bool) {
+	return q.PopUnless(func() bool { return false })
+}
+
+// PopUnless blocks like Pop, but also returns false once stop reports true. Stop is checked
+// before popping and whenever waiters are woken up, e.g by Wake.
+func (q *scheduleQueue) PopUnless(stop func() bool) (*HookSchedule, bool) {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	for {
+		if q.closed {
+			return nil, false
+		}
+
+		if stop() {
+			// hand the wake up over to another waiter, as this one leaves the schedule queued
+			if q.size > 0 {
+				q.notEmpty.Signal()
+			}
+			return nil, false
+		}
+
+		if q.size > 0 {
+			break
+		}
+
+		q.notEmpty.Wait()
+	}
+
+	schedule := q.pop()
+	q.notFull.Signal()
+
+	return schedule, true
+}
+
+func (q *scheduleQueue) Len() int {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	return q.size
+}
+
+// Free returns how many schedules can be pushed without blocking.
+func (q *scheduleQueue) Free() int {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	if q.closed {
+		return 0
+	}
+
+	return q.capacity - q.size
+}
+
+// Wake wakes up the waiters of Pop, so they check their stop condition again.
+func (q *scheduleQueue) Wake() {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	q.notEmpty.Broadcast()
+}
+
+func (q *scheduleQueue) Close() {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+}
+
+// Drain closes the queue and returns the schedules left in it, in pop order.
+func (q *scheduleQueue) Drain() []*HookSchedule {
+	q.l.Lock()
+	defer q.l.Unlock()
+
+	var res []*HookSchedule
+	for q.size > 0 {
+		res = append(res, q.pop())
+	}
+
+	q.closed = true
+	q.notEmpty.Broadcast()
+	q.notFull.Broadcast()
+
+	return res
+}
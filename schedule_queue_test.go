@@ -0,0 +1,82 @@
+package nautilus
+
+import "testing"
+
+func TestScheduleQueue_Priority(t *testing.T) {
+	q := newScheduleQueue(10)
+
+	q.Push(&HookSchedule{ID: "analytics-1", Priority: 0})
+	q.Push(&HookSchedule{ID: "payment-1", Priority: 10})
+	q.Push(&HookSchedule{ID: "analytics-2", Priority: 0})
+	q.Push(&HookSchedule{ID: "payment-2", Priority: 10})
+
+	expected := []string{"payment-1", "payment-2", "analytics-1", "analytics-2"}
+	for _, id := range expected {
+		s, ok := q.Pop()
+		if !ok {
+			t.Fatalf("expected schedule %s, got closed queue", id)
+		}
+		if s.ID != id {
+			t.Errorf("expected schedule %s, got %s", id, s.ID)
+		}
+	}
+
+	q.Close()
+	if _, ok := q.Pop(); ok {
+		t.Error("expected closed queue to return false")
+	}
+}
+
+func TestScheduleQueue_Drain(t *testing.T) {
+	q := newScheduleQueue(10)
+
+	q.Push(&HookSchedule{ID: "analytics-1", Priority: 0})
+	q.Push(&HookSchedule{ID: "payment-1", Priority: 10})
+
+	drained := q.Drain()
+	if len(drained) != 2 || drained[0].ID != "payment-1" || drained[1].ID != "analytics-1" {
+		t.Fatalf("expected schedules in pop order, got %v", drained)
+	}
+
+	if q.Push(&HookSchedule{ID: "analytics-2"}) {
+		t.Error("expected push to a drained queue to be dropped")
+	}
+	if _, ok := q.Pop(); ok {
+		t.Error("expected drained queue to be closed")
+	}
+}
+
+func TestScheduleQueue_Fair(t *testing.T) {
+	q := newFairScheduleQueue(10, func(s *HookSchedule) string { return s.HookConfigurationID })
+
+	q.Push(&HookSchedule{ID: "noisy-1", HookConfigurationID: "noisy"})
+	q.Push(&HookSchedule{ID: "noisy-2", HookConfigurationID: "noisy"})
+	q.Push(&HookSchedule{ID: "noisy-3", HookConfigurationID: "noisy", Priority: 10})
+	q.Push(&HookSchedule{ID: "quiet-1", HookConfigurationID: "quiet"})
+
+	if !q.TryPush(&HookSchedule{ID: "other-1", HookConfigurationID: "other"}) {
+		t.Fatalf("expected schedule to be pushed")
+	}
+
+	expected := []string{"noisy-3", "quiet-1", "other-1", "noisy-1", "noisy-2"}
+	for _, id := range expected {
+		s, ok := q.Pop()
+		if !ok {
+			t.Fatalf("expected schedule %s, got closed queue", id)
+		}
+		if s.ID != id {
+			t.Errorf("expected schedule %s, got %s", id, s.ID)
+		}
+	}
+}
+
+func TestScheduleQueue_TryPush(t *testing.T) {
+	q := newScheduleQueue(1)
+
+	if !q.TryPush(&HookSchedule{ID: "payment-1"}) {
+		t.Fatalf("expected schedule to be pushed")
+	}
+	if q.TryPush(&HookSchedule{ID: "payment-2"}) {
+		t.Errorf("expected full queue to refuse schedule")
+	}
+}
@@ -0,0 +1,172 @@
+package nautilus
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultLeaderLockID is the postgres advisory lock held by the leader unless WithLeaderLockID is given.
+const DefaultLeaderLockID int64 = 0x6e617574696c7573 // "nautilus"
+
+// LeaderScheduler runs the wrapped scheduler only while this instance holds a session level
+// postgres advisory lock, so a single instance dispatches schedules. Workers of every instance
+// still execute the schedules dispatched to them, e.g by Nautilus.ScheduleAndExecute.
+//
+// The lock is held by a dedicated connection, which is checked at every heartbeat. Once the
+// leader dies its session ends, releasing the lock to the next instance trying to acquire it.
+type LeaderScheduler struct {
+	db                *sql.DB
+	scheduler         NautilusScheduler
+	lockID            int64
+	heartbeatInterval time.Duration
+	electionInterval  time.Duration
+	leading           atomic.Bool
+}
+
+func NewLeaderScheduler(db *sql.DB, scheduler NautilusScheduler, options ...func(*LeaderScheduler)) *LeaderScheduler {
+	p := &LeaderScheduler{
+		db:                db,
+		scheduler:         scheduler,
+		lockID:            DefaultLeaderLockID,
+		heartbeatInterval: 5 * time.Second,  // default value
+		electionInterval:  10 * time.Second, // default value
+	}
+
+	for i := range options {
+		options[i](p)
+	}
+
+	return p
+}
+
+// WithLeaderLockID sets the advisory lock of the election, e.g to run several independent
+// deployments against the same database.
+func WithLeaderLockID(lockID int64) func(*LeaderScheduler) {
+	return func(p *LeaderScheduler) {
+		p.lockID = lockID
+	}
+}
+
+// WithHeartbeatInterval sets how often the leader checks the connection holding the lock.
+// It bounds for how long a leader which lost its session keeps dispatching.
+func WithHeartbeatInterval(heartbeatInterval time.Duration) func(*LeaderScheduler) {
+	return func(p *LeaderScheduler) {
+		p.heartbeatInterval = heartbeatInterval
+	}
+}
+
+// WithElectionInterval sets how often followers try to acquire the lock.
+func WithElectionInterval(electionInterval time.Duration) func(*LeaderScheduler) {
+	return func(p *LeaderScheduler) {
+		p.electionInterval = electionInterval
+	}
+}
+
+// IsLeader reports whether this instance currently runs the wrapped scheduler.
+func (p *LeaderScheduler) IsLeader() bool {
+	return p.leading.Load()
+}
+
+// Notify forwards the schedules to the wrapped scheduler while leading. Followers ignore them,
+// as the leader finds them on its own.
+func (p *LeaderScheduler) Notify(schedules ...*HookSchedule) {
+	if !p.leading.Load() {
+		return
+	}
+
+	if scheduler, ok := p.scheduler.(NotifiableScheduler); ok {
+		scheduler.Notify(schedules...)
+	}
+}
+
+// SetCapacity forwards the capacity of the workers to the wrapped scheduler.
+func (p *LeaderScheduler) SetCapacity(capacity func() int) {
+	if scheduler, ok := p.scheduler.(CapacityAwareScheduler); ok {
+		scheduler.SetCapacity(capacity)
+	}
+}
+
+func (p *LeaderScheduler) Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	for {
+		err := p.lead(ctx, scheduleCh, errCh)
+		if err != nil && ctx.Err() == nil && errCh != nil {
+			errCh <- err
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(p.electionInterval):
+		}
+	}
+}
+
+// lead tries to acquire the lock, running the wrapped scheduler until the lock is lost or
+// ctx is done. It returns without error when another instance is the leader.
+func (p *LeaderScheduler) lead(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) error {
+	conn, err := p.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+
+	var acquired bool
+	err = conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", p.lockID).Scan(&acquired)
+	if err != nil || !acquired {
+		discardConn(conn)
+		return err
+	}
+
+	leaderCtx, cancel := context.WithCancel(ctx)
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.scheduler.Start(leaderCtx, scheduleCh, errCh)
+	}()
+	p.leading.Store(true)
+
+	err = p.heartbeat(leaderCtx, conn)
+
+	// the wrapped scheduler must be over before returning, as scheduleCh is closed afterwards
+	p.leading.Store(false)
+	cancel()
+	wg.Wait()
+
+	if err == nil {
+		_, err = conn.ExecContext(context.WithoutCancel(ctx), "SELECT pg_advisory_unlock($1)", p.lockID)
+	}
+
+	// the session is ended instead of returned to the pool, so the lock cannot be kept by mistake
+	discardConn(conn)
+
+	return err
+}
+
+// heartbeat pings the connection holding the lock until it fails or ctx is done.
+func (p *LeaderScheduler) heartbeat(ctx context.Context, conn *sql.Conn) error {
+	ticker := time.NewTicker(p.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			heartbeatCtx, cancel := context.WithTimeout(ctx, p.heartbeatInterval)
+			err := conn.PingContext(heartbeatCtx)
+			cancel()
+			if err != nil && ctx.Err() == nil {
+				return err
+			}
+		}
+	}
+}
+
+func discardConn(conn *sql.Conn) {
+	_ = conn.Raw(func(any) error { return driver.ErrBadConn })
+	_ = conn.Close()
+}
@@ -0,0 +1,138 @@
+package nautilus
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+type blockingScheduler struct {
+	started chan struct{}
+	stopped chan struct{}
+}
+
+func (p *blockingScheduler) Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	p.started <- struct{}{}
+	<-ctx.Done()
+	p.stopped <- struct{}{}
+}
+
+func newBlockingScheduler() *blockingScheduler {
+	return &blockingScheduler{started: make(chan struct{}, 1), stopped: make(chan struct{}, 1)}
+}
+
+func TestLeaderScheduler_Follower(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WithArgs(DefaultLeaderLockID).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(false))
+
+	wrapped := newBlockingScheduler()
+	scheduler := NewLeaderScheduler(db, wrapped)
+	err = scheduler.lead(context.Background(), make(chan *HookSchedule), nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if scheduler.IsLeader() {
+		t.Errorf("Expected instance not to be the leader")
+	}
+
+	select {
+	case <-wrapped.started:
+		t.Errorf("Expected wrapped scheduler not to start on a follower")
+	default:
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLeaderScheduler_Leader(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WithArgs(int64(42)).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectExec(`SELECT pg_advisory_unlock`).
+		WithArgs(int64(42)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wrapped := newBlockingScheduler()
+	scheduler := NewLeaderScheduler(db, wrapped, WithLeaderLockID(42), WithHeartbeatInterval(time.Hour))
+
+	done := make(chan error)
+	go func() {
+		done <- scheduler.lead(ctx, make(chan *HookSchedule), nil)
+	}()
+
+	select {
+	case <-wrapped.started:
+	case <-time.After(time.Second):
+		t.Fatalf("Expected wrapped scheduler to start on the leader")
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case <-wrapped.stopped:
+	default:
+		t.Errorf("Expected wrapped scheduler to stop before returning")
+	}
+
+	if scheduler.IsLeader() {
+		t.Errorf("Expected leadership to be over")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLeaderScheduler_HeartbeatFailure(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery(`SELECT pg_try_advisory_lock`).
+		WithArgs(DefaultLeaderLockID).
+		WillReturnRows(sqlmock.NewRows([]string{"pg_try_advisory_lock"}).AddRow(true))
+	mock.ExpectPing().WillReturnError(errors.New("connection lost"))
+
+	wrapped := newBlockingScheduler()
+	scheduler := NewLeaderScheduler(db, wrapped, WithHeartbeatInterval(10*time.Millisecond))
+
+	err = scheduler.lead(context.Background(), make(chan *HookSchedule), nil)
+	if err == nil {
+		t.Fatalf("Expected heartbeat error")
+	}
+
+	select {
+	case <-wrapped.stopped:
+	default:
+		t.Errorf("Expected wrapped scheduler to stop once the lock is lost")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,118 @@
+package nautilus
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// HookSchedulesNotifyChannel is the postgres channel notified with the ID of every inserted schedule.
+const HookSchedulesNotifyChannel = "nautilus_hook_schedules"
+
+// ListenScheduler dispatches schedules as soon as postgres notifies their insertion, and keeps
+// a slow PollScheduler sweep for retries and notifications missed while disconnected.
+type ListenScheduler struct {
+	connStr              string
+	minReconnectInterval time.Duration
+	maxReconnectInterval time.Duration
+	scheduleReader       HookScheduleReader
+	sweeper              *PollScheduler
+}
+
+// NewListenScheduler listens on a dedicated connection opened with connStr. Options configure
+// the sweep, which runs every minute unless WithRunnerInterval is given.
+func NewListenScheduler(connStr string, scheduleReader HookScheduleReader, options ...func(*PollScheduler)) *ListenScheduler {
+	return &ListenScheduler{
+		connStr:              connStr,
+		minReconnectInterval: 10 * time.Second,
+		maxReconnectInterval: time.Minute,
+		scheduleReader:       scheduleReader,
+		sweeper: NewPollScheduler(scheduleReader,
+			append([]func(*PollScheduler){WithRunnerInterval(time.Minute)}, options...)...),
+	}
+}
+
+// SetCapacity limits the schedules claimed by the sweep to the capacity of the workers.
+func (p *ListenScheduler) SetCapacity(capacity func() int) {
+	p.sweeper.SetCapacity(capacity)
+}
+
+func (p *ListenScheduler) Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	reportError := func(err error) {
+		if err != nil && errCh != nil {
+			errCh <- err
+		}
+	}
+
+	listener := pq.NewListener(p.connStr, p.minReconnectInterval, p.maxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			reportError(err)
+		})
+	defer listener.Close()
+
+	err := listener.Listen(HookSchedulesNotifyChannel)
+	if err != nil {
+		reportError(err)
+	}
+
+	p.listen(ctx, listener.NotificationChannel(), scheduleCh, errCh)
+}
+
+func (p *ListenScheduler) listen(ctx context.Context, notifications <-chan *pq.Notification, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	// the sweep must be over before returning, as scheduleCh is closed afterwards
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.sweeper.Start(ctx, scheduleCh, errCh)
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case n := <-notifications:
+			// a nil notification follows a reconnection, so notifications may have been missed
+			if n == nil {
+				p.sweeper.poll(ctx, scheduleCh, errCh)
+				continue
+			}
+
+			schedule, err := p.findSchedule(ctx, n.Extra)
+			if err == ErrNotFound {
+				continue
+			}
+
+			if err != nil {
+				if errCh != nil {
+					errCh <- err
+				}
+				continue
+			}
+
+			scheduleCh <- schedule
+		}
+	}
+}
+
+// findSchedule claims the notified schedule when possible, as every instance listening is notified.
+func (p *ListenScheduler) findSchedule(ctx context.Context, id string) (*HookSchedule, error) {
+	if claimer, ok := p.scheduleReader.(HookScheduleClaimer); ok {
+		return claimer.ClaimHookSchedule(ctx, id, p.sweeper.instanceID, p.sweeper.claimLease)
+	}
+
+	schedule, _, err := p.scheduleReader.FindHookSchedulesByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if schedule.Status != HookScheduleStatusScheduled {
+		return nil, ErrNotFound
+	}
+
+	return schedule, nil
+}
@@ -0,0 +1,72 @@
+package nautilus
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func TestListenScheduler_Listen(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	persister := NewInMemoryPersister()
+	now := time.Now().UTC()
+	err := persister.WriteHookSchedules(ctx, []*HookSchedule{
+		{ID: "notified", Status: HookScheduleStatusScheduled, CreatedAt: now},
+		{ID: "executed", Status: HookScheduleStatusExecuted, CreatedAt: now},
+		{ID: "missed", Status: HookScheduleStatusScheduled, CreatedAt: now},
+	})
+	if err != nil {
+		t.Fatalf("Failed to write schedules: %v", err)
+	}
+
+	scheduler := NewListenScheduler("", persister, WithRunnerInterval(time.Hour), WithInstanceID("instance-1"))
+	notifications := make(chan *pq.Notification)
+	scheduleCh := make(chan *HookSchedule)
+	stopped := make(chan struct{})
+	go func() {
+		scheduler.listen(ctx, notifications, scheduleCh, nil)
+		close(stopped)
+	}()
+
+	notifications <- &pq.Notification{Channel: HookSchedulesNotifyChannel, Extra: "executed"}
+	notifications <- &pq.Notification{Channel: HookSchedulesNotifyChannel, Extra: "notified"}
+
+	select {
+	case schedule := <-scheduleCh:
+		if schedule.ID != "notified" {
+			t.Errorf("Expected notified schedule, got %s", schedule.ID)
+		}
+		if schedule.ClaimedBy == nil || *schedule.ClaimedBy != "instance-1" {
+			t.Errorf("Expected notified schedule to be claimed")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Notified schedule was not dispatched")
+	}
+
+	// notified again, but already claimed
+	notifications <- &pq.Notification{Channel: HookSchedulesNotifyChannel, Extra: "notified"}
+
+	// reconnection sweeps the schedules missed
+	notifications <- nil
+
+	select {
+	case schedule := <-scheduleCh:
+		if schedule.ID != "missed" {
+			t.Errorf("Expected missed schedule to be swept, got %s", schedule.ID)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Missed schedule was not swept")
+	}
+
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("Scheduler did not stop")
+	}
+}
@@ -3,19 +3,29 @@ package nautilus
 import (
 	"context"
 	"time"
+
+	"github.com/devmalloni/nautilus/x"
 )
 
+// PollScheduler periodically reads the scheduled schedules. When the reader is also a
+// HookScheduleClaimer, the schedules are claimed instead, so several instances can poll
+// the same persister without delivering the same attempt twice.
 type PollScheduler struct {
-	runnerInterval       time.Duration
-	skipScheduleInterval time.Duration
-	scheduleReader       HookScheduleReader
+	runnerInterval time.Duration
+	scheduleReader HookScheduleReader
+	instanceID     string
+	claimLease     time.Duration
+	pageSize       int
+	capacity       func() int
 }
 
 func NewPollScheduler(scheduleReader HookScheduleReader, options ...func(*PollScheduler)) *PollScheduler {
 	p := &PollScheduler{
-		runnerInterval:       10 * time.Second, // default value
-		skipScheduleInterval: 40 * time.Second, // default value
-		scheduleReader:       scheduleReader,
+		runnerInterval: 10 * time.Second, // default value
+		scheduleReader: scheduleReader,
+		instanceID:     x.NewUUIDStr(),
+		claimLease:     5 * time.Minute,
+		pageSize:       1000,
 	}
 
 	for i := range options {
@@ -25,10 +35,10 @@ func NewPollScheduler(scheduleReader HookScheduleReader, options ...func(*PollSc
 	return p
 }
 
+// Deprecated: retries are due at their next attempt, set by WithRetryInterval. This option
+// has no effect.
 func WithSkipScheduleInterval(skipScheduleInterval time.Duration) func(*PollScheduler) {
-	return func(p *PollScheduler) {
-		p.skipScheduleInterval = skipScheduleInterval
-	}
+	return func(p *PollScheduler) {}
 }
 
 func WithRunnerInterval(runnerInterval time.Duration) func(*PollScheduler) {
@@ -37,26 +47,93 @@ func WithRunnerInterval(runnerInterval time.Duration) func(*PollScheduler) {
 	}
 }
 
+// WithInstanceID identifies the instance claiming schedules, e.g the pod name.
+func WithInstanceID(instanceID string) func(*PollScheduler) {
+	return func(p *PollScheduler) {
+		p.instanceID = instanceID
+	}
+}
+
+// WithClaimLease sets for how long claimed schedules are reserved to this instance. It must
+// be longer than a delivery, as the schedules are claimable again once the lease is over.
+func WithClaimLease(claimLease time.Duration) func(*PollScheduler) {
+	return func(p *PollScheduler) {
+		p.claimLease = claimLease
+	}
+}
+
+// WithPageSize sets the max number of due schedules read, or claimed, at once. Each poll
+// pages through the due schedules until a page is not full.
+func WithPageSize(pageSize int) func(*PollScheduler) {
+	return func(p *PollScheduler) {
+		p.pageSize = pageSize
+	}
+}
+
+// SetCapacity limits the schedules claimed at once to the capacity of the workers, so claimed
+// schedules do not outlive their lease waiting for a worker.
+func (p *PollScheduler) SetCapacity(capacity func() int) {
+	p.capacity = capacity
+}
+
+// findSchedules returns the page of due schedules after the given schedule, and whether the
+// page is full. Claimed schedules are not claimable again, so claiming does not need the cursor.
+func (p *PollScheduler) findSchedules(ctx context.Context, now time.Time, after *HookSchedule) ([]*HookSchedule, bool, error) {
+	claimer, ok := p.scheduleReader.(HookScheduleClaimer)
+	if !ok {
+		schedules, err := p.scheduleReader.FindDueHookSchedules(ctx, now, after, p.pageSize)
+		return schedules, p.pageSize > 0 && len(schedules) >= p.pageSize, err
+	}
+
+	limit := p.pageSize
+	if p.capacity != nil {
+		capacity := p.capacity()
+		if capacity <= 0 {
+			return nil, false, nil
+		}
+		if limit <= 0 || capacity < limit {
+			limit = capacity
+		}
+	}
+
+	schedules, err := claimer.ClaimScheduledHookSchedules(ctx, p.instanceID, p.claimLease, limit)
+	return schedules, limit > 0 && len(schedules) >= limit, err
+}
+
 func (p *PollScheduler) Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-time.After(p.runnerInterval):
-			now := time.Now().UTC()
-			schedules, err := p.scheduleReader.FindScheduledHookSchedules(ctx)
-			if err != nil && errCh != nil {
+			p.poll(ctx, scheduleCh, errCh)
+		}
+	}
+}
+
+func (p *PollScheduler) poll(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	now := time.Now().UTC()
+	var after *HookSchedule
+	for ctx.Err() == nil {
+		schedules, full, err := p.findSchedules(ctx, now, after)
+		if err != nil {
+			if errCh != nil {
 				errCh <- err
-				continue
 			}
+			return
+		}
 
-			for i := range schedules {
-				if schedules[i].CurrentAttempt == 0 ||
-					schedules[i].UpdatedAt == nil ||
-					schedules[i].UpdatedAt.UTC().Before(now.Add(-p.skipScheduleInterval)) {
-					scheduleCh <- schedules[i]
-				}
-			}
+		if len(schedules) > 0 {
+			after = schedules[len(schedules)-1]
+		}
+
+		// due schedules are read by priority, so the most urgent are dispatched first
+		for i := range schedules {
+			scheduleCh <- schedules[i]
+		}
+
+		if !full {
+			return
 		}
 	}
 }
@@ -0,0 +1,87 @@
+package nautilus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// PushScheduler dispatches schedules as soon as Nautilus notifies them, delaying retries and
+// debounced schedules in a timer wheel. A slow PollScheduler sweep recovers the schedules
+// never notified, such as the ones written by ScheduleTx or blocked by their ordering key.
+type PushScheduler struct {
+	notifyCh chan *HookSchedule
+	wheel    *timerWheel
+	sweeper  *PollScheduler
+}
+
+// NewPushScheduler creates a scheduler whose retries wait for their next attempt.
+// Options configure the sweep, which runs every minute unless WithRunnerInterval is given.
+func NewPushScheduler(scheduleReader HookScheduleReader, options ...func(*PollScheduler)) *PushScheduler {
+	return &PushScheduler{
+		notifyCh: make(chan *HookSchedule, 1024),
+		wheel:    newTimerWheel(10*time.Millisecond, 1024),
+		sweeper: NewPollScheduler(scheduleReader,
+			append([]func(*PollScheduler){WithRunnerInterval(time.Minute)}, options...)...),
+	}
+}
+
+// Notify hands the schedules to the scheduler without blocking. Schedules notified while the
+// scheduler is busy are left to the sweep.
+func (p *PushScheduler) Notify(schedules ...*HookSchedule) {
+	for _, schedule := range schedules {
+		if schedule.Status != HookScheduleStatusScheduled {
+			continue
+		}
+
+		select {
+		case p.notifyCh <- schedule:
+		default:
+		}
+	}
+}
+
+func (p *PushScheduler) Start(ctx context.Context, scheduleCh chan *HookSchedule, errCh chan<- error) {
+	// the sweep must be over before returning, as scheduleCh is closed afterwards
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		p.sweeper.Start(ctx, scheduleCh, errCh)
+	}()
+
+	ticker := time.NewTicker(p.wheel.tick)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case schedule := <-p.notifyCh:
+			if delay := p.delay(schedule, time.Now()); delay > 0 {
+				p.wheel.add(schedule, delay)
+				continue
+			}
+			scheduleCh <- schedule
+		case <-ticker.C:
+			for _, schedule := range p.wheel.advance() {
+				scheduleCh <- schedule
+			}
+		}
+	}
+}
+
+// delay returns how long the schedule waits before its next attempt.
+func (p *PushScheduler) delay(schedule *HookSchedule, now time.Time) time.Duration {
+	if delay := schedule.NextAttempt().Sub(now); delay > 0 {
+		return delay
+	}
+
+	if schedule.IsDebouncing(now) {
+		return schedule.debounceEnd().Sub(now)
+	}
+
+	return 0
+}
@@ -0,0 +1,54 @@
+package nautilus
+
+import "time"
+
+// timerWheel is a hashed timing wheel delaying schedules by a number of ticks. Adding and
+// advancing cost O(1) per schedule regardless of how many schedules are delayed.
+// It is not safe for concurrent use.
+type timerWheel struct {
+	tick    time.Duration
+	slots   [][]timerWheelEntry
+	current int
+}
+
+type timerWheelEntry struct {
+	schedule *HookSchedule
+	rounds   int
+}
+
+func newTimerWheel(tick time.Duration, size int) *timerWheel {
+	return &timerWheel{
+		tick:  tick,
+		slots: make([][]timerWheelEntry, size),
+	}
+}
+
+// add delays the schedule by at least one tick, rounding delay up to whole ticks.
+func (w *timerWheel) add(s *HookSchedule, delay time.Duration) {
+	ticks := int((delay + w.tick - 1) / w.tick)
+	if ticks < 1 {
+		ticks = 1
+	}
+
+	slot := (w.current + ticks) % len(w.slots)
+	w.slots[slot] = append(w.slots[slot], timerWheelEntry{schedule: s, rounds: (ticks - 1) / len(w.slots)})
+}
+
+// advance moves the wheel one tick, returning the schedules due.
+func (w *timerWheel) advance() []*HookSchedule {
+	w.current = (w.current + 1) % len(w.slots)
+
+	var due []*HookSchedule
+	pending := w.slots[w.current][:0]
+	for _, entry := range w.slots[w.current] {
+		if entry.rounds > 0 {
+			entry.rounds--
+			pending = append(pending, entry)
+			continue
+		}
+		due = append(due, entry.schedule)
+	}
+	w.slots[w.current] = pending
+
+	return due
+}
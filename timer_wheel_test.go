@@ -0,0 +1,31 @@
+package nautilus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimerWheel(t *testing.T) {
+	w := newTimerWheel(10*time.Millisecond, 4)
+
+	w.add(&HookSchedule{ID: "next-tick"}, 0)
+	w.add(&HookSchedule{ID: "third-tick"}, 25*time.Millisecond)
+	w.add(&HookSchedule{ID: "sixth-tick"}, 60*time.Millisecond)
+
+	expected := map[int]string{1: "next-tick", 3: "third-tick", 6: "sixth-tick"}
+	for tick := 1; tick <= 8; tick++ {
+		due := w.advance()
+
+		id, ok := expected[tick]
+		if !ok {
+			if len(due) != 0 {
+				t.Errorf("expected nothing due at tick %d, got %d schedules", tick, len(due))
+			}
+			continue
+		}
+
+		if len(due) != 1 || due[0].ID != id {
+			t.Errorf("expected %s due at tick %d, got %v", id, tick, due)
+		}
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"crypto/sha256"
 	"crypto/x509"
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"encoding/pem"
 	"errors"
@@ -16,16 +17,61 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"path"
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/devmalloni/nautilus/x"
+	"github.com/lib/pq"
 )
 
+var (
+	ErrPayloadFiltered  = errors.New("payload does not match the configuration filter")
+	ErrDuplicatePayload = errors.New("payload duplicates a recent schedule")
+)
+
+// DuplicatePayloadError is returned when a payload was dropped for every configuration, as
+// a duplicate within the deduplication window of their definition.
+type DuplicatePayloadError struct {
+	// Originals are the schedules the payload duplicates, one per configuration.
+	Originals []*HookSchedule
+}
+
+func (e *DuplicatePayloadError) Error() string {
+	ids := make([]string, len(e.Originals))
+	for i := range e.Originals {
+		ids[i] = e.Originals[i].ID
+	}
+
+	return fmt.Sprintf("%v: %s", ErrDuplicatePayload, strings.Join(ids, ", "))
+}
+
+func (e *DuplicatePayloadError) Is(target error) bool {
+	return target == ErrDuplicatePayload
+}
+
+// IdempotencyConflictError is returned when an idempotency key is reused with a different payload.
+type IdempotencyConflictError struct {
+	IdempotencyKey string
+	// HookSchedule is the schedule originally created with the key.
+	HookSchedule *HookSchedule
+}
+
+func (e *IdempotencyConflictError) Error() string {
+	return fmt.Sprintf("idempotency key %q was already used by schedule %s with a different payload",
+		e.IdempotencyKey, e.HookSchedule.ID)
+}
+
 const (
 	ClientSecretHeader    = "X-Client-Secret"
 	ClientSignatureHeader = "X-Client-Signature"
 )
 
+// defaultDebounceMaxWaitWindows is how many debounce windows a schedule waits at most when
+// its definition has no debounce max wait.
+const defaultDebounceMaxWaitWindows = 10
+
 const (
 	GET    HttpRequestMethod = "GET"
 	POST   HttpRequestMethod = "POST"
@@ -37,6 +83,13 @@ const (
 	HookScheduleStatusScheduled HookScheduleStatus = "scheduled"
 	HookScheduleStatusExecuted  HookScheduleStatus = "executed"
 	HookScheduleStatusFailed    HookScheduleStatus = "failed"
+	// HookScheduleStatusQuarantined is set on schedules whose delivery panicked repeatedly.
+	HookScheduleStatusQuarantined HookScheduleStatus = "quarantined"
+)
+
+const (
+	OrderingFailurePolicyBlock OrderingFailurePolicy = "block"
+	OrderingFailurePolicySkip  OrderingFailurePolicy = "skip"
 )
 
 type (
@@ -48,6 +101,8 @@ type (
 	HookConfigurationTag string
 	HookScheduleStatus   string
 
+	OrderingFailurePolicy string
+
 	HookDefinition struct {
 		ID string `json:"id,omitempty" yaml:"id" db:"id"`
 		/*
@@ -83,6 +138,56 @@ type (
 		* Specifies if payload must be sent in raw or if it will be included in execution metadata (e.g. sent at, definition id and unique execution id)
 		 */
 		HideExecutionMetadata bool `json:"hide_execution_metadata,omitempty" yaml:"hide_execution_metadata" db:"hide_execution_metadata"`
+
+		/*
+		* Specifies what happens to schedules sharing an ordering key with a schedule that failed.
+		* block (default) holds them until the failed schedule is resolved, skip delivers them anyway.
+		* With block, a failed schedule is only resolved by requeueing it, e.g with Requeue, and the
+		* schedules it holds are checked again every retry interval
+		 */
+		OrderingFailurePolicy OrderingFailurePolicy `json:"ordering_failure_policy,omitempty" yaml:"ordering_failure_policy" db:"ordering_failure_policy"`
+
+		/*
+		* Dispatch priority of schedules of this definition. Higher values are delivered first,
+		* except across the tags of a worker pool that serves them in turns, see WorkerPool.Fair
+		 */
+		Priority int `json:"priority,omitempty" yaml:"priority" db:"priority"`
+
+		/*
+		* Max number of schedules of a configuration delivered together in a single request.
+		* Batching is disabled unless higher than 1
+		 */
+		BatchMaxSize int `json:"batch_max_size,omitempty" yaml:"batch_max_size" db:"batch_max_size"`
+		/*
+		* Max time a schedule waits for its batch to be filled before it is delivered
+		*
+		* e.g 5s
+		 */
+		BatchMaxWait time.Duration `json:"batch_max_wait,omitempty" yaml:"batch_max_wait" db:"batch_max_wait"`
+
+		/*
+		* Time a schedule with a coalescing key waits for newer payloads of the same key,
+		* which replace its payload, before it is delivered
+		*
+		* e.g 30s
+		 */
+		DebounceWindow time.Duration `json:"debounce_window,omitempty" yaml:"debounce_window" db:"debounce_window"`
+
+		/*
+		* Max time a debounced schedule waits since it was created, so a steady stream of
+		* payloads does not delay its delivery indefinitely. Defaults to ten debounce windows
+		*
+		* e.g 5m
+		 */
+		DebounceMaxWait time.Duration `json:"debounce_max_wait,omitempty" yaml:"debounce_max_wait" db:"debounce_max_wait"`
+
+		/*
+		* Time within which a payload equal to one already scheduled or delivered
+		* to the same configuration is dropped as a duplicate
+		*
+		* e.g 10m
+		 */
+		DeduplicationWindow time.Duration `json:"deduplication_window,omitempty" yaml:"deduplication_window" db:"deduplication_window"`
 	}
 
 	HookConfiguration struct {
@@ -94,9 +199,47 @@ type (
 		ClientSecret        *string `json:"client_secret,omitempty" yaml:"client_secret" db:"client_secret"`
 		ClientRSAPrivateKey *string `json:"-,omitempty" yaml:"client_rsa_private_key" db:"client_rsa_private_key"`
 
+		/*
+		* Disabled configurations do not receive new schedules
+		 */
+		Disabled bool `json:"disabled,omitempty" yaml:"disabled" db:"disabled"`
+
+		/*
+		* Predicate the payload must match to be scheduled to this configuration
+		*
+		* e.g $.status == "shipped"
+		 */
+		Filter *string `json:"filter,omitempty" yaml:"filter" db:"filter"`
+
+		/*
+		* Set when the configuration was created from a subscription
+		 */
+		SubscriptionID *string `json:"subscription_id,omitempty" yaml:"subscription_id" db:"subscription_id"`
+
 		CreatedAt time.Time `json:"created_at,omitempty" yaml:"created_at" db:"created_at"`
 
 		HookDefinition *HookDefinition `json:"hook_definition,omitempty"`
+
+		// payloadFilter is Filter parsed on register
+		payloadFilter *PayloadFilter
+	}
+
+	HookSubscription struct {
+		ID  string               `json:"id,omitempty" yaml:"id" db:"id"`
+		Tag HookConfigurationTag `json:"tag,omitempty" yaml:"tag" db:"tag"`
+		URL string               `json:"url,omitempty" yaml:"url" db:"url"`
+		/*
+		* Hook definition IDs or patterns the endpoint subscribes to
+		*
+		* e.g [order.*, customer_created]
+		 */
+		HookDefinitionPatterns pq.StringArray `json:"hook_definition_patterns,omitempty" yaml:"hook_definition_patterns" db:"hook_definition_patterns"`
+
+		ClientSecret        *string `json:"client_secret,omitempty" yaml:"client_secret" db:"client_secret"`
+		ClientRSAPrivateKey *string `json:"-,omitempty" yaml:"client_rsa_private_key" db:"client_rsa_private_key"`
+		Filter              *string `json:"filter,omitempty" yaml:"filter" db:"filter"`
+
+		CreatedAt time.Time `json:"created_at,omitempty" yaml:"created_at" db:"created_at"`
 	}
 
 	HookSchedule struct {
@@ -107,22 +250,51 @@ type (
 		Payload             json.RawMessage    `json:"payload,omitempty" db:"payload"`
 		Status              HookScheduleStatus `json:"status,omitempty" db:"status"`
 
+		// Tag the schedule was requested for, which is not the tag of its configuration when
+		// resolved through a TagFallback.
+		Tag HookConfigurationTag `json:"tag,omitempty" db:"tag"`
+
 		MaxAttempt            int  `json:"max_attempt,omitempty" db:"max_attempt"`
 		CurrentAttempt        int  `json:"current_attempt,omitempty" db:"current_attempt"`
+		PanicCount            int  `json:"panic_count,omitempty" db:"panic_count"`
 		HideExecutionMetadata bool `json:"hide_execution_metadata,omitempty" db:"hide_execution_metadata"`
 
+		OrderingKey    *string `json:"ordering_key,omitempty" db:"ordering_key"`
+		Priority       int     `json:"priority,omitempty" db:"priority"`
+		GroupID        *string `json:"group_id,omitempty" db:"group_id"`
+		IdempotencyKey *string `json:"idempotency_key,omitempty" db:"idempotency_key"`
+		CoalescingKey  *string `json:"coalescing_key,omitempty" db:"coalescing_key"`
+		PayloadHash    *string `json:"payload_hash,omitempty" db:"payload_hash"`
+
+		NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+
+		ClaimedBy    *string    `json:"claimed_by,omitempty" db:"claimed_by"`
+		ClaimedUntil *time.Time `json:"claimed_until,omitempty" db:"claimed_until"`
+
 		CreatedAt time.Time  `json:"created_at,omitempty" db:"created_at"`
 		UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
 
 		HookConfiguration *HookConfiguration `json:"hook_configuration,omitempty"`
 	}
 
+	// DeadLetterFilter selects dead letters. Unset fields match every dead letter, and the
+	// failed time is when the last attempt was made. Tag matches the tag the dead letters were
+	// requested for, which is not the tag of their configuration when resolved to a fallback.
+	DeadLetterFilter struct {
+		HookDefinitionID *string
+		Tag              *HookConfigurationTag
+		FailedAfter      *time.Time
+		FailedBefore     *time.Time
+		Limit            int
+	}
+
 	HookExecution struct {
 		ID              string    `json:"id,omitempty" db:"id"`
 		HookScheduleID  string    `json:"hook_schedule_id,omitempty" db:"hook_schedule_id"`
 		RequestPayload  *string   `json:"request_payload,omitempty" db:"request_payload"`
 		ResponsePayload *string   `json:"response_payload,omitempty" db:"response_payload"`
 		ResponseStatus  int       `json:"response_status,omitempty" db:"response_status"`
+		BatchID         *string   `json:"batch_id,omitempty" db:"batch_id"`
 		CreatedAt       time.Time `json:"created_at,omitempty" db:"created_at"`
 	}
 
@@ -132,6 +304,43 @@ type (
 		HookDefinitionID string          `json:"hook_definition_id,omitempty"`
 		Data             json.RawMessage `json:"data,omitempty"`
 	}
+
+	HookScheduleGroupStatus struct {
+		GroupID  string                     `json:"group_id,omitempty"`
+		Total    int                        `json:"total"`
+		Statuses map[HookScheduleStatus]int `json:"statuses,omitempty"`
+	}
+
+	HookRecurringSchedule struct {
+		ID                  string `json:"id,omitempty" yaml:"id" db:"id"`
+		HookConfigurationID string `json:"hook_configuration_id,omitempty" yaml:"hook_configuration_id" db:"hook_configuration_id"`
+		/*
+		* Standard five field cron expression or descriptor
+		*
+		* e.g 0 9 * * 1-5 or @daily
+		 */
+		CronExpression string `json:"cron_expression,omitempty" yaml:"cron_expression" db:"cron_expression"`
+		/*
+		* IANA time zone used to evaluate the cron expression. Defaults to UTC
+		*
+		* e.g America/Sao_Paulo
+		 */
+		TimeZone string `json:"time_zone,omitempty" yaml:"time_zone" db:"time_zone"`
+		/*
+		* Static payload sent on every firing. Ignored if PayloadGenerator is set
+		 */
+		Payload json.RawMessage `json:"payload,omitempty" yaml:"payload" db:"payload"`
+		/*
+		* Name of a PayloadGenerator registered with WithPayloadGenerator, called on every firing
+		 */
+		PayloadGenerator *string `json:"payload_generator,omitempty" yaml:"payload_generator" db:"payload_generator"`
+
+		NextRunAt time.Time  `json:"next_run_at,omitempty" yaml:"next_run_at" db:"next_run_at"`
+		LastRunAt *time.Time `json:"last_run_at,omitempty" yaml:"last_run_at" db:"last_run_at"`
+		CreatedAt time.Time  `json:"created_at,omitempty" yaml:"created_at" db:"created_at"`
+
+		HookConfiguration *HookConfiguration `json:"hook_configuration,omitempty"`
+	}
 )
 
 func (p *HookDefinition) CreateConfiguration(id, url string,
@@ -169,9 +378,28 @@ func (p *HookDefinition) IsValid() error {
 		return errors.New("total attempts must be higher than 0")
 	}
 
+	if p.BatchMaxSize < 0 || p.BatchMaxWait < 0 {
+		return errors.New("batch max size and max wait must not be negative")
+	}
+
+	if p.DebounceWindow < 0 || p.DebounceMaxWait < 0 || p.DeduplicationWindow < 0 {
+		return errors.New("debounce and deduplication windows must not be negative")
+	}
+
+	switch p.OrderingFailurePolicy {
+	case "", OrderingFailurePolicyBlock, OrderingFailurePolicySkip:
+	default:
+		return errors.New("ordering failure policy is not valid")
+	}
+
 	return nil
 }
 
+// IsBatched reports whether schedules of this definition are delivered in batches.
+func (p *HookDefinition) IsBatched() bool {
+	return p.BatchMaxSize > 1
+}
+
 func (p *HookConfiguration) IsValid() error {
 	_, err := url.ParseRequestURI(p.URL)
 	if err != nil {
@@ -190,9 +418,20 @@ func (p *HookConfiguration) IsValid() error {
 		return errors.New("hook definition is not set")
 	}
 
+	p.payloadFilter = nil
+	if p.Filter != nil {
+		filter, err := ParsePayloadFilter(*p.Filter)
+		if err != nil {
+			return err
+		}
+		p.payloadFilter = filter
+	}
+
 	return nil
 }
 
+// Schedule creates a schedule of payload to this configuration. It returns ErrPayloadFiltered
+// if the payload does not match the configuration filter.
 func (p *HookConfiguration) Schedule(id string, payload json.RawMessage, validator JSchemaValidator) (*HookSchedule, error) {
 	if p.HookDefinition.PayloadScheme != nil && validator != nil {
 		if err := validator.Validate(p.HookDefinition.PayloadScheme, payload); err != nil {
@@ -200,19 +439,51 @@ func (p *HookConfiguration) Schedule(id string, payload json.RawMessage, validat
 		}
 	}
 
+	if p.Filter != nil {
+		// configurations read back from a persister are parsed again
+		filter := p.payloadFilter
+		if filter == nil || filter.source != *p.Filter {
+			var err error
+			filter, err = ParsePayloadFilter(*p.Filter)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		match, err := filter.Match(payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if !match {
+			return nil, ErrPayloadFiltered
+		}
+	}
+
 	s := &HookSchedule{
 		ID:                    id,
 		HookConfigurationID:   p.ID,
+		Tag:                   p.Tag,
 		URL:                   p.URL,
 		Payload:               payload,
 		HttpRequestMethod:     p.HookDefinition.HttpRequestMethod,
 		Status:                HookScheduleStatusScheduled,
 		MaxAttempt:            p.HookDefinition.TotalAttempts,
 		HideExecutionMetadata: p.HookDefinition.HideExecutionMetadata,
+		Priority:              p.HookDefinition.Priority,
 		HookConfiguration:     p,
 		CurrentAttempt:        0,
 		CreatedAt:             time.Now().UTC(),
 	}
+	s.NextAttemptAt = &s.CreatedAt
+
+	if p.HookDefinition.DeduplicationWindow > 0 {
+		hash, err := PayloadHash(payload)
+		if err != nil {
+			return nil, err
+		}
+		s.PayloadHash = &hash
+	}
 
 	if err := s.IsValid(); err != nil {
 		return nil, err
@@ -262,7 +533,21 @@ func (p *HookSchedule) IsValid() error {
 	if p.HookConfiguration == nil {
 		return errors.New("hook configuration is not set")
 	}
-	url, err := url.Parse(p.URL)
+	err := validateURL(p.URL)
+	if err != nil {
+		return err
+	}
+
+	if p.MaxAttempt <= 0 {
+		return errors.New("max attempt must be higher than 0")
+	}
+
+	return nil
+}
+
+// validateURL checks that a schedule can be delivered to rawURL.
+func validateURL(rawURL string) error {
+	url, err := url.Parse(rawURL)
 	if err != nil {
 		return err
 	}
@@ -271,11 +556,103 @@ func (p *HookSchedule) IsValid() error {
 		return errors.New("url scheme must be http or https")
 	}
 
-	if p.MaxAttempt <= 0 {
-		return errors.New("max attempt must be higher than 0")
+	return nil
+}
+
+// CanonicalJSON re-encodes a json payload with sorted object keys and no insignificant
+// whitespace, so equal payloads have equal encodings.
+func CanonicalJSON(payload json.RawMessage) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(payload))
+	d.UseNumber()
+
+	var v any
+	if err := d.Decode(&v); err != nil {
+		return nil, err
 	}
 
-	return nil
+	return json.Marshal(v)
+}
+
+// PayloadHash is the hex encoded sha256 of the canonical json of the payload.
+func PayloadHash(payload json.RawMessage) (string, error) {
+	canonical, err := CanonicalJSON(payload)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(canonical)
+
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// HasSamePayload reports whether both schedules carry the same json payload,
+// regardless of formatting and key order.
+func (p *HookSchedule) HasSamePayload(other *HookSchedule) bool {
+	var a, b any
+	if err := json.Unmarshal(p.Payload, &a); err != nil {
+		return bytes.Equal(p.Payload, other.Payload)
+	}
+	if err := json.Unmarshal(other.Payload, &b); err != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// NextAttempt returns when the schedule is due, defaulting to its creation for schedules
+// written before next attempts were tracked.
+func (p *HookSchedule) NextAttempt() time.Time {
+	if p.NextAttemptAt != nil {
+		return *p.NextAttemptAt
+	}
+
+	return p.CreatedAt
+}
+
+// IsDebouncing reports whether the schedule still waits for newer payloads of its coalescing key.
+func (p *HookSchedule) IsDebouncing(now time.Time) bool {
+	if p.CoalescingKey == nil || p.CurrentAttempt > 0 || p.HookConfiguration == nil ||
+		p.HookConfiguration.HookDefinition == nil || p.HookConfiguration.HookDefinition.DebounceWindow <= 0 {
+		return false
+	}
+
+	return now.Before(p.debounceEnd())
+}
+
+// debounceEnd returns when the debounce window since the last payload elapses, capped by
+// the debounce max wait since the schedule was created.
+func (p *HookSchedule) debounceEnd() time.Time {
+	definition := p.HookConfiguration.HookDefinition
+
+	lastUpdate := p.CreatedAt
+	if p.UpdatedAt != nil {
+		lastUpdate = *p.UpdatedAt
+	}
+
+	maxWait := definition.DebounceMaxWait
+	if maxWait <= 0 {
+		maxWait = defaultDebounceMaxWaitWindows * definition.DebounceWindow
+	}
+
+	end := lastUpdate.Add(definition.DebounceWindow)
+	if deadline := p.CreatedAt.Add(maxWait); deadline.Before(end) {
+		return deadline
+	}
+
+	return end
+}
+
+// IsBlockedBy reports whether an earlier schedule sharing the ordering key
+// must be resolved before this one can be delivered.
+func (p *HookSchedule) IsBlockedBy(predecessor *HookSchedule, policy OrderingFailurePolicy) bool {
+	switch predecessor.Status {
+	case HookScheduleStatusScheduled:
+		return true
+	case HookScheduleStatusFailed, HookScheduleStatusQuarantined:
+		return policy != OrderingFailurePolicySkip
+	default:
+		return false
+	}
 }
 
 func (p *HookSchedule) Execute(ctx context.Context, executionID string, client *http.Client) (*HookExecution, error) {
@@ -295,34 +672,133 @@ func (p *HookSchedule) Execute(ctx context.Context, executionID string, client *
 	requestPayload := string(b)
 	e.RequestPayload = &requestPayload
 
+	e.ResponseStatus, e.ResponsePayload, err = p.HookConfiguration.send(ctx, client, p.HttpRequestMethod, p.URL, b)
+	if err != nil {
+		return nil, err
+	}
+
+	p.registerAttempt(e.ResponseStatus)
+
+	return e, nil
+}
+
+// ExecuteBatch delivers the schedules in a single request whose body is a json array with
+// the execution data of every schedule. All schedules must share the same configuration,
+// and they are marked executed or failed together. One execution is returned per schedule.
+func ExecuteBatch(ctx context.Context, batchID string, schedules []*HookSchedule, client *http.Client) ([]*HookExecution, error) {
+	if len(schedules) == 0 {
+		return nil, errors.New("batch has no schedules")
+	}
+
+	now := time.Now().UTC()
+	executions := make([]*HookExecution, len(schedules))
+	requestData := make([]any, len(schedules))
+	for i, s := range schedules {
+		if s.HookConfigurationID != schedules[0].HookConfigurationID {
+			return nil, errors.New("batch schedules must share the same hook configuration")
+		}
+
+		executions[i] = &HookExecution{
+			ID:             x.NewUUIDStr(),
+			HookScheduleID: s.ID,
+			BatchID:        &batchID,
+			CreatedAt:      now,
+		}
+		requestData[i] = s.executionData(executions[i])
+	}
+
+	b, err := json.Marshal(requestData)
+	if err != nil {
+		return nil, err
+	}
+	requestPayload := string(b)
+
+	first := schedules[0]
+	responseStatus, responsePayload, err := first.HookConfiguration.send(ctx, client, first.HttpRequestMethod, first.URL, b)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, s := range schedules {
+		executions[i].RequestPayload = &requestPayload
+		executions[i].ResponseStatus = responseStatus
+		executions[i].ResponsePayload = responsePayload
+
+		s.registerAttempt(responseStatus)
+	}
+
+	return executions, nil
+}
+
+func (p *HookSchedule) registerAttempt(responseStatus int) {
+	p.CurrentAttempt++
+	if responseStatus == http.StatusOK {
+		p.Status = HookScheduleStatusExecuted
+	} else if p.CurrentAttempt > p.MaxAttempt {
+		p.Status = HookScheduleStatusFailed
+	}
+	p.UpdatedAt = x.NilTime(time.Now().UTC())
+}
+
+// IsDeadLetter reports whether the schedule was given up on, failing all its attempts or
+// being quarantined.
+func (p *HookSchedule) IsDeadLetter() bool {
+	return p.Status == HookScheduleStatusFailed || p.Status == HookScheduleStatusQuarantined
+}
+
+// requeue schedules a dead letter again with all its attempts. Its executions are kept.
+func (p *HookSchedule) requeue() {
+	now := time.Now().UTC()
+	p.Status = HookScheduleStatusScheduled
+	p.CurrentAttempt = 0
+	p.PanicCount = 0
+	p.NextAttemptAt = &now
+	p.UpdatedAt = &now
+}
+
+// registerPanic registers a delivery which panicked as a failed attempt, quarantining the
+// schedule once it panicked quarantineThreshold times.
+func (p *HookSchedule) registerPanic(quarantineThreshold int) {
+	p.PanicCount++
+	p.registerAttempt(0)
+	if quarantineThreshold > 0 && p.PanicCount >= quarantineThreshold {
+		p.Status = HookScheduleStatusQuarantined
+	}
+}
+
+func (p *HookConfiguration) send(ctx context.Context,
+	client *http.Client,
+	method HttpRequestMethod,
+	url string,
+	b []byte) (int, *string, error) {
 	buff := bytes.NewBuffer(b)
 	req, err := http.NewRequestWithContext(ctx,
-		string(p.HttpRequestMethod),
-		p.URL,
+		string(method),
+		url,
 		buff)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
-	if p.HookConfiguration.ClientSecret != nil {
-		req.Header.Set(ClientSecretHeader, *p.HookConfiguration.ClientSecret)
+	if p.ClientSecret != nil {
+		req.Header.Set(ClientSecretHeader, *p.ClientSecret)
 	}
 
-	if p.HookConfiguration.ClientRSAPrivateKey != nil {
-		rsaSignature, err := signBody(b, *p.HookConfiguration.ClientRSAPrivateKey)
+	if p.ClientRSAPrivateKey != nil {
+		rsaSignature, err := signBody(b, *p.ClientRSAPrivateKey)
 		if err != nil {
-			return nil, err
+			return 0, nil, err
 		}
 		req.Header.Set(ClientSignatureHeader, rsaSignature)
 	}
 
 	resp, err := client.Do(req)
 	if err != nil {
-		return nil, err
+		return 0, nil, err
 	}
+	defer resp.Body.Close()
 
-	e.ResponseStatus = resp.StatusCode
 	responseBytes, err := io.ReadAll(resp.Body)
 	var responsePayload string
 	if err != nil {
@@ -330,31 +806,12 @@ func (p *HookSchedule) Execute(ctx context.Context, executionID string, client *
 	} else {
 		responsePayload = string(responseBytes)
 	}
-	e.ResponsePayload = &responsePayload
-
-	p.CurrentAttempt++
-	if resp.StatusCode == http.StatusOK {
-		p.Status = HookScheduleStatusExecuted
-	} else if p.CurrentAttempt > p.MaxAttempt {
-		p.Status = HookScheduleStatusFailed
-	}
-	p.UpdatedAt = x.NilTime(time.Now().UTC())
 
-	return e, nil
+	return resp.StatusCode, &responsePayload, nil
 }
 
 func (p *HookSchedule) createExecutionData(e *HookExecution) ([]byte, error) {
-	var requestData any
-	if p.HideExecutionMetadata {
-		requestData = p.Payload
-	} else {
-		requestData = HookExecutionData{
-			ID:               e.HookScheduleID,
-			SentAt:           time.Now().UTC(),
-			HookDefinitionID: p.HookConfiguration.HookDefinitionID,
-			Data:             p.Payload,
-		}
-	}
+	requestData := p.executionData(e)
 
 	b, err := json.Marshal(&requestData)
 	if err != nil {
@@ -364,6 +821,19 @@ func (p *HookSchedule) createExecutionData(e *HookExecution) ([]byte, error) {
 	return b, nil
 }
 
+func (p *HookSchedule) executionData(e *HookExecution) any {
+	if p.HideExecutionMetadata {
+		return p.Payload
+	}
+
+	return HookExecutionData{
+		ID:               e.HookScheduleID,
+		SentAt:           time.Now().UTC(),
+		HookDefinitionID: p.HookConfiguration.HookDefinitionID,
+		Data:             p.Payload,
+	}
+}
+
 // SignBody signs the body using SHA256 and RSA.
 func signBody(body []byte, privateKey string) (string, error) {
 	rsaPrivateKey, err := rsaPemToPrivateKey(privateKey)
@@ -415,6 +885,123 @@ func rsaPemToPrivateKey(pemstr string) (*rsa.PrivateKey, error) {
 	return privKey, nil
 }
 
+func (p *HookSubscription) IsValid() error {
+	if p.ID == "" {
+		return errors.New("id is required")
+	}
+
+	_, err := url.ParseRequestURI(p.URL)
+	if err != nil {
+		return err
+	}
+
+	if p.Tag == "" {
+		return errors.New("tag is required")
+	}
+
+	if len(p.HookDefinitionPatterns) == 0 {
+		return errors.New("at least one hook definition pattern is required")
+	}
+
+	for _, pattern := range p.HookDefinitionPatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid hook definition pattern %q: %w", pattern, err)
+		}
+	}
+
+	if p.Filter != nil {
+		if _, err := ParsePayloadFilter(*p.Filter); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Matches reports whether the subscription covers the hook definition.
+func (p *HookSubscription) Matches(hookDefinitionID string) bool {
+	for _, pattern := range p.HookDefinitionPatterns {
+		if ok, _ := path.Match(pattern, hookDefinitionID); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Configuration returns the configuration that delivers schedules of definition to the
+// subscription endpoint. Its ID is derived from the subscription and definition IDs.
+func (p *HookSubscription) Configuration(definition *HookDefinition) *HookConfiguration {
+	return &HookConfiguration{
+		ID:                  p.ID + ":" + definition.ID,
+		HookDefinitionID:    definition.ID,
+		Tag:                 p.Tag,
+		URL:                 p.URL,
+		ClientSecret:        p.ClientSecret,
+		ClientRSAPrivateKey: p.ClientRSAPrivateKey,
+		Filter:              p.Filter,
+		SubscriptionID:      &p.ID,
+		CreatedAt:           p.CreatedAt,
+		HookDefinition:      definition,
+	}
+}
+
+func (p *HookRecurringSchedule) IsValid() error {
+	if p.ID == "" {
+		return errors.New("id is required")
+	}
+
+	if p.HookConfigurationID == "" {
+		return errors.New("hook configuration id is required")
+	}
+
+	if p.HookConfiguration == nil {
+		return errors.New("hook configuration is not set")
+	}
+
+	if _, err := ParseCronExpression(p.CronExpression); err != nil {
+		return err
+	}
+
+	if _, err := time.LoadLocation(p.TimeZone); err != nil {
+		return err
+	}
+
+	if p.Payload == nil && p.PayloadGenerator == nil {
+		return errors.New("payload or payload generator is required")
+	}
+
+	return nil
+}
+
+// Next returns the first firing of the recurring schedule strictly after t.
+func (p *HookRecurringSchedule) Next(t time.Time) (time.Time, error) {
+	cron, err := ParseCronExpression(p.CronExpression)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	loc, err := time.LoadLocation(p.TimeZone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	next, err := cron.Next(t.In(loc))
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return next.UTC(), nil
+}
+
+// Fire materializes the hook schedule of the firing at firedAt. The schedule id is derived
+// from the recurring schedule id and the firing time, so a firing is scheduled only once.
+func (p *HookRecurringSchedule) Fire(firedAt time.Time, payload json.RawMessage, validator JSchemaValidator) (*HookSchedule, error) {
+	id := fmt.Sprintf("%s@%d", p.ID, firedAt.Unix())
+
+	return p.HookConfiguration.Schedule(id, payload, validator)
+}
+
 func (p *HookConfiguration) PublicKey() (*rsa.PublicKey, error) {
 	privKey, err := rsaPemToPrivateKey(*p.ClientRSAPrivateKey)
 	if err != nil {
@@ -323,3 +323,58 @@ func TestHookSchedule_Execute_HideExecutionMetadata(t *testing.T) {
 		return
 	}
 }
+
+func TestHookConfiguration_Schedule_Filter(t *testing.T) {
+	filter := `$.status == "shipped"`
+	hc := HookConfiguration{
+		ID:               "config-id",
+		HookDefinitionID: "test-definition",
+		URL:              "http://example.com/hook",
+		Tag:              "test-tag",
+		Filter:           &filter,
+		HookDefinition: &HookDefinition{
+			ID:                "test-definition",
+			TotalAttempts:     3,
+			HttpRequestMethod: POST,
+		},
+	}
+
+	_, err := hc.Schedule("schedule-id", json.RawMessage(`{"status": "shipped"}`), nil)
+	if err != nil {
+		t.Errorf("expected no error at hc.Schedule with matching payload, got %v", err)
+	}
+
+	_, err = hc.Schedule("schedule-id", json.RawMessage(`{"status": "pending"}`), nil)
+	if err != ErrPayloadFiltered {
+		t.Errorf("expected ErrPayloadFiltered at hc.Schedule with non-matching payload, got %v", err)
+	}
+}
+
+func TestPayloadHash(t *testing.T) {
+	a, err := PayloadHash(json.RawMessage(`{"b": {"y": 1, "x": 2.50}, "a": [1, "<"]}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	b, err := PayloadHash(json.RawMessage(`{"a":[1,"<"],"b":{"x":2.50,"y":1}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if a != b {
+		t.Errorf("Expected equal hashes for equal payloads, got %s and %s", a, b)
+	}
+
+	c, err := PayloadHash(json.RawMessage(`{"a":[1,"<"],"b":{"x":2.51,"y":1}}`))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if a == c {
+		t.Error("Expected different hashes for different payloads")
+	}
+
+	if _, err := PayloadHash(json.RawMessage(`{`)); err == nil {
+		t.Error("Expected error for invalid payload")
+	}
+}
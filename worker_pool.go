@@ -0,0 +1,258 @@
+package nautilus
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	ErrInvalidWorkersCount = errors.New("workers count must be at least 1 and max must not be lower than min")
+)
+
+// workerPool runs between min and max workers popping schedules from the queue. The pool
+// is scaled by autoscale from the queue depth and the delivery latency, or resized by the
+// user at runtime. Workers above the target size retire before popping another schedule.
+type workerPool struct {
+	l       sync.Mutex
+	min     int
+	max     int
+	size    int
+	running int
+	busy    int
+	latency time.Duration
+	stopped bool
+
+	wg      sync.WaitGroup
+	queue   *scheduleQueue
+	execute func(schedule *HookSchedule)
+}
+
+func newWorkerPool(min, max int, queue *scheduleQueue, execute func(schedule *HookSchedule)) *workerPool {
+	return &workerPool{
+		min:     min,
+		max:     max,
+		size:    min,
+		queue:   queue,
+		execute: execute,
+	}
+}
+
+func validWorkersCount(min, max int) error {
+	if min < 1 || max < min {
+		return ErrInvalidWorkersCount
+	}
+
+	return nil
+}
+
+func (w *workerPool) start() {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.spawn()
+}
+
+// spawn starts workers up to the target size. It must be called with the lock held.
+func (w *workerPool) spawn() {
+	for !w.stopped && w.running < w.size {
+		w.running++
+		w.wg.Add(1)
+		go w.work()
+	}
+}
+
+func (w *workerPool) work() {
+	defer w.wg.Done()
+
+	retired := false
+	retire := func() bool {
+		retired = w.retire()
+		return retired
+	}
+
+	for {
+		schedule, ok := w.queue.PopUnless(retire)
+		if !ok {
+			break
+		}
+
+		w.setBusy(1)
+		started := time.Now()
+		w.execute(schedule)
+		w.observe(time.Since(started))
+	}
+
+	if !retired {
+		w.l.Lock()
+		w.running--
+		w.l.Unlock()
+	}
+}
+
+// retire reports whether the calling worker must leave the pool, as there are more workers
+// running than the target size.
+func (w *workerPool) retire() bool {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	if w.running <= w.size {
+		return false
+	}
+
+	w.running--
+	return true
+}
+
+func (w *workerPool) setBusy(delta int) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.busy += delta
+}
+
+// observe records a delivery latency in an exponential moving average.
+func (w *workerPool) observe(latency time.Duration) {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	w.busy--
+	if w.latency == 0 {
+		w.latency = latency
+		return
+	}
+	w.latency = (4*w.latency + latency) / 5
+}
+
+func (w *workerPool) resize(min, max int) error {
+	if err := validWorkersCount(min, max); err != nil {
+		return err
+	}
+
+	w.l.Lock()
+	w.min, w.max = min, max
+	w.size = clamp(w.size, min, max)
+	w.spawn()
+	shrinking := w.running > w.size
+	w.l.Unlock()
+
+	// idle workers are waiting on the queue, and check whether to retire once woken up
+	if shrinking {
+		w.queue.Wake()
+	}
+
+	return nil
+}
+
+func (w *workerPool) workers() int {
+	w.l.Lock()
+	defer w.l.Unlock()
+
+	return w.running
+}
+
+// capacity returns how many schedules the pool can take right away: one per worker it may
+// still put to work, up to its max, and the free slots of its queue.
+func (w *workerPool) capacity() int {
+	w.l.Lock()
+	idle := max(w.max-w.busy, 0)
+	w.l.Unlock()
+
+	return idle + w.queue.Free()
+}
+
+// autoscale scales the pool at every interval until ctx is done.
+func (w *workerPool) autoscale(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.scale(interval)
+		}
+	}
+}
+
+// scale grows the pool right away to the workers needed by the queued schedules, and shrinks
+// it one worker at a time, so a short lull does not drop the workers of a sustained load.
+func (w *workerPool) scale(interval time.Duration) {
+	depth := w.queue.Len()
+
+	w.l.Lock()
+	desired := clamp(desiredWorkers(w.busy, depth, w.latency, interval), w.min, w.max)
+	if desired < w.size {
+		desired = w.size - 1
+	}
+	w.size = desired
+	w.spawn()
+	shrinking := w.running > w.size
+	w.l.Unlock()
+
+	if shrinking {
+		w.queue.Wake()
+	}
+}
+
+func (w *workerPool) stop() {
+	w.l.Lock()
+	w.stopped = true
+	w.l.Unlock()
+
+	w.wg.Wait()
+}
+
+// desiredWorkers estimates the workers needed to keep the busy ones and drain the queue
+// within one interval, each worker delivering interval/latency schedules per interval.
+// Until a latency is observed, a worker is assumed to deliver a single schedule.
+func desiredWorkers(busy, depth int, latency, interval time.Duration) int {
+	perWorker := 1
+	if latency > 0 && latency < interval {
+		perWorker = int(interval / latency)
+	}
+
+	return busy + (depth+perWorker-1)/perWorker
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
+
+// ResizeWorkers changes the bounds of the worker pool. The pool of the running Run, if any,
+// scales into the new bounds right away, and the next Run starts with them.
+func (p *Nautilus) ResizeWorkers(min, max int) error {
+	if err := validWorkersCount(min, max); err != nil {
+		return err
+	}
+
+	p.runLock.Lock()
+	defer p.runLock.Unlock()
+
+	p.minWorkersCount, p.maxWorkersCount = min, max
+	if p.running == nil || p.running.pool == nil {
+		return nil
+	}
+
+	return p.running.pool.resize(min, max)
+}
+
+// Workers returns the number of workers of the running Run.
+func (p *Nautilus) Workers() int {
+	p.runLock.Lock()
+	defer p.runLock.Unlock()
+
+	if p.running == nil || p.running.pool == nil {
+		return 0
+	}
+
+	return p.running.pool.workers()
+}
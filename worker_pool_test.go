@@ -0,0 +1,132 @@
+package nautilus
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDesiredWorkers(t *testing.T) {
+	tests := []struct {
+		name     string
+		busy     int
+		depth    int
+		latency  time.Duration
+		expected int
+	}{
+		{name: "idle", expected: 0},
+		{name: "busy without queue", busy: 3, expected: 3},
+		{name: "unknown latency", busy: 1, depth: 4, expected: 5},
+		{name: "fast deliveries", busy: 1, depth: 10, latency: 250 * time.Millisecond, expected: 4},
+		{name: "slow deliveries", depth: 3, latency: 2 * time.Second, expected: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res := desiredWorkers(tt.busy, tt.depth, tt.latency, time.Second)
+			if res != tt.expected {
+				t.Errorf("Expected %d workers, got %d", tt.expected, res)
+			}
+		})
+	}
+}
+
+func TestWorkerPool_Scale(t *testing.T) {
+	queue := newScheduleQueue(100)
+	release := make(chan struct{})
+	pool := newWorkerPool(1, 4, queue, func(schedule *HookSchedule) {
+		<-release
+	})
+	pool.start()
+
+	for i := 0; i < 6; i++ {
+		queue.Push(&HookSchedule{ID: string(rune('a' + i))})
+	}
+
+	// one busy worker and five queued schedules, capped to max
+	waitFor(t, func() bool { return pool.workers() == 1 && queue.Len() == 5 })
+	pool.scale(time.Second)
+	if pool.workers() != 4 {
+		t.Fatalf("Expected pool to grow to 4 workers, got %d", pool.workers())
+	}
+
+	close(release)
+	waitFor(t, func() bool { return queue.Len() == 0 })
+
+	// idle workers shrink one at a time
+	waitFor(t, func() bool { pool.scale(time.Second); return pool.workers() == 1 })
+
+	err := pool.resize(2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pool.workers() != 2 {
+		t.Errorf("Expected pool to be resized to 2 workers, got %d", pool.workers())
+	}
+
+	err = pool.resize(3, 2)
+	if err != ErrInvalidWorkersCount {
+		t.Errorf("Expected ErrInvalidWorkersCount, got %v", err)
+	}
+
+	queue.Close()
+	pool.stop()
+	if pool.workers() != 0 {
+		t.Errorf("Expected no workers after stop, got %d", pool.workers())
+	}
+}
+
+func waitFor(t *testing.T, condition func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !condition() {
+		if time.Now().After(deadline) {
+			t.Fatalf("Condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestNautilus_ResizeWorkers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	n := New(WithWorkerPoolSize(1, 3), WithScheduler(NewPollScheduler(NewInMemoryPersister(), WithRunnerInterval(time.Hour))))
+	if n.Workers() != 0 {
+		t.Errorf("Expected no workers before Run, got %d", n.Workers())
+	}
+
+	done := make(chan struct{})
+	go func() {
+		n.Run(ctx)
+		close(done)
+	}()
+
+	waitFor(t, func() bool { return n.Workers() == 1 })
+
+	err := n.ResizeWorkers(2, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitFor(t, func() bool { return n.Workers() == 2 })
+
+	if err := n.ResizeWorkers(0, 2); err != ErrInvalidWorkersCount {
+		t.Errorf("Expected ErrInvalidWorkersCount, got %v", err)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestNautilus_WorkerPoolSizeClamped(t *testing.T) {
+	n := New(WithWorkerPoolSize(0, -1))
+	if n.minWorkersCount != 1 || n.maxWorkersCount != 1 {
+		t.Errorf("Expected bounds clamped to 1 and 1, got %d and %d", n.minWorkersCount, n.maxWorkersCount)
+	}
+
+	n = New(WithWorkerPoolSize(4, 2))
+	if n.minWorkersCount != 4 || n.maxWorkersCount != 4 {
+		t.Errorf("Expected max clamped to min, got %d and %d", n.minWorkersCount, n.maxWorkersCount)
+	}
+}
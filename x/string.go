@@ -3,3 +3,11 @@ package x
 func NullString(t string) *string {
 	return &t
 }
+
+func EqualNullString(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}